@@ -12,25 +12,27 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/jmoiron/sqlx"
+	"github.com/ozaitsev92/gonewsbot/internal/api"
 	"github.com/ozaitsev92/gonewsbot/internal/bot"
 	"github.com/ozaitsev92/gonewsbot/internal/bot/middleware"
 	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+	"github.com/ozaitsev92/gonewsbot/internal/cluster"
 	"github.com/ozaitsev92/gonewsbot/internal/config"
 	"github.com/ozaitsev92/gonewsbot/internal/fetcher"
+	"github.com/ozaitsev92/gonewsbot/internal/filter"
+	"github.com/ozaitsev92/gonewsbot/internal/leader"
 	"github.com/ozaitsev92/gonewsbot/internal/notifier"
+	"github.com/ozaitsev92/gonewsbot/internal/ranker"
+	"github.com/ozaitsev92/gonewsbot/internal/ratelimit"
+	"github.com/ozaitsev92/gonewsbot/internal/source"
 	"github.com/ozaitsev92/gonewsbot/internal/storage"
 	"github.com/ozaitsev92/gonewsbot/internal/summary"
+	"github.com/ozaitsev92/gonewsbot/internal/websub"
 )
 
 func main() {
 	cfg := config.Get()
 
-	botAPI, err := tgbotapi.NewBotAPI(cfg.TelegramBotToken)
-	if err != nil {
-		slog.Error("failed to create bot API", "error", err)
-		return
-	}
-
 	db, err := sqlx.Connect("postgres", cfg.DatabaseDSN)
 	if err != nil {
 		slog.Error("failed to connect to database", "error", err)
@@ -41,14 +43,71 @@ func main() {
 	articlesStorage := storage.NewArticlePostgresStorage(db)
 	sourcesStorage := storage.NewSourcePostgresStorage(db)
 
+	// CLI subcommands only need the database and exit right away.
+	if len(os.Args) > 1 {
+		if os.Args[1] != "opml" {
+			slog.Error("unknown command", "command", os.Args[1])
+			os.Exit(2)
+		}
+
+		if err := runOPML(context.Background(), sourcesStorage, os.Args[2:]); err != nil {
+			slog.Error("opml command failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	botAPI, err := tgbotapi.NewBotAPI(cfg.TelegramBotToken)
+	if err != nil {
+		slog.Error("failed to create bot API", "error", err)
+		return
+	}
+	fetchRunsStorage := storage.NewFetchRunPostgresStorage(db)
+	filterRulesStorage := storage.NewFilterRulePostgresStorage(db)
+	filters := filter.NewEngine(filterRulesStorage, cfg.FilterKeywords)
+
+	feedClient := &http.Client{Timeout: 30 * time.Second}
+	// The fetcher goes easy on the sites that host many of the sources. The
+	// request timeout is kept by the transport, so that the wait for the
+	// bucket of a busy site does not count against it.
+	fetchClient := &http.Client{
+		Transport: ratelimit.NewHostLimiter(cfg.FetchHostInterval, cfg.FetchHostBurst).Transport(http.DefaultTransport, 30*time.Second),
+	}
+
 	aFetcher := fetcher.New(
 		articlesStorage,
 		sourcesStorage,
+		fetchRunsStorage,
+		bot.NewAdminAlerter(botAPI, cfg.TelegramChannelID),
+		fetcher.NewSourceFactory(fetchClient, cfg.GitHubToken),
+		fetcher.HealthPolicy{
+			MaxConsecutiveFailures: cfg.SourceMaxFailures,
+			MaxIdle:                cfg.SourceMaxIdle,
+		},
+		filters,
 		cfg.FetchInterval,
-		cfg.FilterKeywords,
+		cfg.FetchSchedulerTick,
+		cfg.FetchMaxBackoff,
+		cfg.FetchConcurrency,
+		cfg.FetchTimeout,
+	)
+
+	aFetcher.SetLanguages(cfg.AllowedLanguages, cfg.ForeignLanguages == "translate")
+	aFetcher.SetClusterer(cluster.New(articlesStorage, cfg.ClusterMaxDistance, cfg.ClusterWindow))
+
+	rankedArticles := ranker.NewProvider(
+		articlesStorage,
+		ranker.New(cfg.RankPriorityWeight, cfg.RankFreshnessHalfLife, cfg.RankFairnessWeight),
+		cfg.RankCandidates,
+		cfg.RankFairnessWindow,
 	)
 
+	// The notifier posts the articles published within two fetches, a source
+	// cannot be fetched less often than that.
+	publishWindow := 2 * cfg.FetchInterval
+
 	aNotifier := notifier.NewNotifier(
+		rankedArticles,
 		articlesStorage,
 		summary.NewOpenAISummarizer(
 			config.Get().OpenAIKey,
@@ -57,22 +116,63 @@ func main() {
 		),
 		botAPI,
 		cfg.NotificationInterval,
-		2*cfg.FetchInterval,
+		publishWindow,
 		cfg.TelegramChannelID,
+		cfg.NotifierMaxAttempts,
+		cfg.NotifierRetryDelay,
 	)
 
+	feedDetector := source.NewDetector(feedClient)
+	pendingSources := bot.NewPendingSources(time.Hour)
+
 	newsBot := botkit.New(botAPI)
-	newsBot.RegisterCmdView("addsource", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdAddSource(sourcesStorage)))
+	newsBot.RegisterCmdView("addsource", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdAddSource(feedDetector, aFetcher, pendingSources)))
+	newsBot.RegisterCallbackView(bot.CallbackAddSource, middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCallbackAddSource(aFetcher, pendingSources)))
+	newsBot.RegisterCallbackView(bot.CallbackConfirmSource, middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCallbackConfirmSource(sourcesStorage, pendingSources)))
+	newsBot.RegisterCmdView("testselectors", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdTestSelectors(aFetcher)))
 	newsBot.RegisterCmdView("setpriority", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdSetPriority(sourcesStorage)))
+	newsBot.RegisterCmdView("setfetchinterval", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdSetFetchInterval(sourcesStorage, publishWindow)))
+	newsBot.RegisterCmdView("setlanguages", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdSetLanguages(sourcesStorage)))
 	newsBot.RegisterCmdView("getsource", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdGetSource(sourcesStorage)))
 	newsBot.RegisterCmdView("listsources", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdListSource(sourcesStorage)))
 	newsBot.RegisterCmdView("deletesource", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdDeleteSource(sourcesStorage)))
+	newsBot.RegisterCmdView("enablesource", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdEnableSource(sourcesStorage)))
+	newsBot.RegisterCmdView("importopml", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdImportOPML(sourcesStorage, feedClient, publishWindow)))
+	newsBot.RegisterCmdView("exportopml", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdExportOPML(sourcesStorage)))
+	newsBot.RegisterCmdView("addrule", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdAddRule(filterRulesStorage)))
+	newsBot.RegisterCmdView("listrules", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdListRules(filterRulesStorage)))
+	newsBot.RegisterCmdView("testrule", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdTestRule(sourcesStorage, filterRulesStorage, filters, aFetcher)))
+	newsBot.RegisterCmdView("health", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdHealth(fetchRunsStorage)))
+	newsBot.RegisterCmdView("fetchstats", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdFetchStats(fetchRunsStorage)))
+	newsBot.RegisterCmdView("failed", middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCmdFailed(articlesStorage)))
+	newsBot.RegisterCallbackView(bot.CallbackFailedArticle, middleware.AdminsOnly(cfg.TelegramChannelID, bot.ViewCallbackFailedArticle(articlesStorage, aNotifier)))
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
 
+	// WebSub needs a public URL of the HTTP server for the hubs to call back.
+	var subscriber *websub.Subscriber
+	if cfg.WebSubCallbackURL != "" {
+		subscriber = websub.NewSubscriber(
+			storage.NewWebSubPostgresStorage(db),
+			aFetcher,
+			feedClient,
+			cfg.WebSubCallbackURL,
+			cfg.WebSubLease,
+		)
+		aFetcher.SetHubSubscriber(subscriber)
+		mux.Handle(websub.CallbackPath+"{id}", subscriber.Handler())
+	}
+
+	// The API stays off until a token is configured.
+	if cfg.APIToken != "" {
+		mux.Handle("POST /api/v1/articles", api.RequireToken(cfg.APIToken, api.HandleSubmitArticles(aFetcher, sourcesStorage)))
+		mux.Handle("GET /api/v1/fetch-reports", api.RequireToken(cfg.APIToken, api.HandleFetchReports(fetchRunsStorage)))
+		mux.Handle("GET /api/v1/failed-articles", api.RequireToken(cfg.APIToken, api.HandleFailedArticles(articlesStorage)))
+	}
+
 	server := &http.Server{
 		Addr:    cfg.HTTPBindAddress,
 		Handler: mux,
@@ -81,20 +181,6 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	// Start fetcher
-	go func() {
-		if err := aFetcher.Start(ctx); err != nil && !errors.Is(err, context.Canceled) {
-			slog.Error("fetcher stopped with error", "error", err)
-		}
-	}()
-
-	// Start notifier
-	go func() {
-		if err := aNotifier.Start(ctx); err != nil && !errors.Is(err, context.Canceled) {
-			slog.Error("notifier stopped with error", "error", err)
-		}
-	}()
-
 	// Start HTTP server
 	go func() {
 		slog.Info("starting HTTP server", "addr", server.Addr)
@@ -103,9 +189,22 @@ func main() {
 		}
 	}()
 
-	// Run Telegram bot (blocking)
-	if err := newsBot.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
-		slog.Error("bot stopped with error", "error", err)
+	workers := []leader.Worker{
+		{Name: "fetcher", Run: aFetcher.Start},
+		{Name: "notifier", Run: aNotifier.Start},
+		{Name: "bot", Run: newsBot.Run},
+	}
+	// Renew WebSub leases
+	if subscriber != nil {
+		workers = append(workers, leader.Worker{Name: "websub subscriber", Run: subscriber.Start})
+	}
+
+	// Every instance serves HTTP, only the leader fetches, posts and talks to Telegram (blocking).
+	// The leadership is given up as soon as one of the workers stops.
+	elector := leader.New(storage.NewAdvisoryLock(db, cfg.LeaderLockKey), cfg.LeaderCheckInterval)
+	err = elector.Run(ctx, leader.Together(workers...))
+	if err != nil && !errors.Is(err, context.Canceled) {
+		slog.Error("leader election stopped with error", "error", err)
 	}
 
 	// Graceful shutdown of HTTP server
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ozaitsev92/gonewsbot/internal/opml"
+	"github.com/ozaitsev92/gonewsbot/internal/storage"
+)
+
+const opmlUsage = "usage: opml import <file> | opml export [file]"
+
+// runOPML imports sources from an OPML file or exports them to a file or stdout.
+func runOPML(ctx context.Context, sources *storage.SourcePostgresStorage, args []string) error {
+	if len(args) == 0 {
+		return errors.New(opmlUsage)
+	}
+
+	switch args[0] {
+	case "import":
+		if len(args) != 2 {
+			return errors.New(opmlUsage)
+		}
+
+		file, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		parsed, err := opml.Parse(file)
+		if err != nil {
+			return err
+		}
+
+		result, err := opml.Import(ctx, sources, parsed)
+		if err != nil {
+			return err
+		}
+
+		for _, err := range result.Errors {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		fmt.Printf("imported %d sources, skipped %d duplicates, failed %d\n", result.Added, result.Skipped, result.Failed)
+
+		return nil
+	case "export":
+		if len(args) > 2 {
+			return errors.New(opmlUsage)
+		}
+
+		all, err := sources.GetSources(ctx)
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if len(args) == 2 {
+			file, err := os.Create(args[1])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		return opml.Write(out, "gonewsbot sources", all)
+	default:
+		return errors.New(opmlUsage)
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const (
+	// defaultPushSource receives the articles submitted without a source name.
+	defaultPushSource = "api"
+	maxBatchSize      = 100
+	maxRequestSize    = 1 << 20
+)
+
+type ArticleIngester interface {
+	Ingest(ctx context.Context, sourceID int64, items []model.Item) (int, error)
+}
+
+type PushSourceStorage interface {
+	EnsurePushSource(ctx context.Context, name string) (int64, error)
+}
+
+type submittedArticle struct {
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	Summary     string    `json:"summary"`
+	PublishedAt time.Time `json:"published_at"`
+	Source      string    `json:"source"`
+}
+
+type submitResponse struct {
+	Received int `json:"received"`
+	Added    int `json:"added"`
+}
+
+// HandleSubmitArticles accepts a single article or a JSON array of them:
+//
+//	{"title": "...", "link": "https://...", "summary": "...", "published_at": "2025-10-01T12:00:00Z", "source": "ci"}
+//
+// Articles without a source go to the "api" push source. The published time
+// defaults to the time of the submission.
+func HandleSubmitArticles(ingester ArticleIngester, sources PushSourceStorage) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		articles, err := decodeArticles(http.MaxBytesReader(w, r.Body, maxRequestSize))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// Articles are grouped by source so that every push source is looked up once.
+		bySource := make(map[string][]model.Item)
+		var order []string
+		for _, article := range articles {
+			name := strings.TrimSpace(article.Source)
+			if name == "" {
+				name = defaultPushSource
+			}
+			if _, ok := bySource[name]; !ok {
+				order = append(order, name)
+			}
+			bySource[name] = append(bySource[name], article.toItem())
+		}
+
+		resp := submitResponse{Received: len(articles)}
+		for _, name := range order {
+			sourceID, err := sources.EnsurePushSource(r.Context(), name)
+			if err != nil {
+				slog.Error("failed to resolve push source", "source", name, "error", err)
+				writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+				return
+			}
+
+			added, err := ingester.Ingest(r.Context(), sourceID, bySource[name])
+			resp.Added += added
+			if err != nil {
+				slog.Error("failed to ingest pushed articles", "source", name, "error", err)
+				writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+				return
+			}
+		}
+
+		writeJSON(w, http.StatusAccepted, resp)
+	}
+}
+
+func decodeArticles(body io.Reader) ([]submittedArticle, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var articles []submittedArticle
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		err = json.Unmarshal(trimmed, &articles)
+	} else {
+		var article submittedArticle
+		err = json.Unmarshal(trimmed, &article)
+		articles = []submittedArticle{article}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+
+	if len(articles) == 0 {
+		return nil, errors.New("no articles submitted")
+	}
+	if len(articles) > maxBatchSize {
+		return nil, fmt.Errorf("at most %d articles can be submitted at once", maxBatchSize)
+	}
+
+	for i, article := range articles {
+		if err := article.validate(); err != nil {
+			return nil, fmt.Errorf("article %d: %w", i, err)
+		}
+	}
+
+	return articles, nil
+}
+
+func (a submittedArticle) validate() error {
+	if strings.TrimSpace(a.Title) == "" {
+		return errors.New("title is required")
+	}
+
+	link, err := url.Parse(strings.TrimSpace(a.Link))
+	if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+		return errors.New("link must be an absolute http(s) URL")
+	}
+
+	return nil
+}
+
+func (a submittedArticle) toItem() model.Item {
+	return model.Item{
+		Title:      strings.TrimSpace(a.Title),
+		Link:       strings.TrimSpace(a.Link),
+		Summary:    a.Summary,
+		Date:       a.PublishedAt,
+		SourceName: a.Source,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("failed to write response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// memoryPush keeps the push sources and the items ingested for them. Links
+// that were ingested before are not added again.
+type memoryPush struct {
+	sources map[string]int64
+	items   map[int64][]model.Item
+	links   map[string]bool
+}
+
+func newMemoryPush() *memoryPush {
+	return &memoryPush{
+		sources: make(map[string]int64),
+		items:   make(map[int64][]model.Item),
+		links:   make(map[string]bool),
+	}
+}
+
+func (p *memoryPush) EnsurePushSource(_ context.Context, name string) (int64, error) {
+	if id, ok := p.sources[name]; ok {
+		return id, nil
+	}
+	p.sources[name] = int64(len(p.sources) + 1)
+
+	return p.sources[name], nil
+}
+
+func (p *memoryPush) Ingest(_ context.Context, sourceID int64, items []model.Item) (int, error) {
+	var added int
+	for _, item := range items {
+		if p.links[item.Link] {
+			continue
+		}
+		p.links[item.Link] = true
+		p.items[sourceID] = append(p.items[sourceID], item)
+		added++
+	}
+
+	return added, nil
+}
+
+func submit(t *testing.T, push *memoryPush, body string) (int, map[string]any) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	HandleSubmitArticles(push, push).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/articles", strings.NewReader(body)))
+
+	var resp map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	return rec.Code, resp
+}
+
+func TestSubmitArticles(t *testing.T) {
+	push := newMemoryPush()
+	push.links["https://example.com/known"] = true
+
+	body := `[
+		{"title": "  Release v2.0  ", "link": "https://ci.example.com/releases/2.0", "published_at": "2025-10-01T12:00:00Z", "source": "ci"},
+		{"title": "Incident resolved", "link": "https://status.example.com/incidents/7", "summary": "All systems operational."},
+		{"title": "Known article", "link": "https://example.com/known", "source": "ci"}
+	]`
+
+	status, resp := submit(t, push, body)
+	if status != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %v", status, http.StatusAccepted, resp)
+	}
+	if resp["received"] != 3.0 || resp["added"] != 2.0 {
+		t.Fatalf("response = %v, want 3 received and 2 added", resp)
+	}
+
+	ci := push.items[push.sources["ci"]]
+	if len(ci) != 1 || ci[0].Title != "Release v2.0" || !ci[0].Date.Equal(time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("items of the ci source = %+v", ci)
+	}
+
+	fallback := push.items[push.sources[defaultPushSource]]
+	if len(fallback) != 1 || fallback[0].Summary != "All systems operational." {
+		t.Errorf("items of the %s source = %+v", defaultPushSource, fallback)
+	}
+}
+
+func TestSubmitSingleArticle(t *testing.T) {
+	push := newMemoryPush()
+
+	status, resp := submit(t, push, `{"title": "Deployed", "link": "https://ci.example.com/deploys/1"}`)
+	if status != http.StatusAccepted || resp["added"] != 1.0 {
+		t.Fatalf("status = %d, response = %v, want one article added", status, resp)
+	}
+}
+
+func TestSubmitArticlesRejected(t *testing.T) {
+	article := `{"title": "Deployed", "link": "https://ci.example.com/deploys/%d"}`
+	batch := func(n int) string {
+		articles := make([]string, n)
+		for i := range articles {
+			articles[i] = fmt.Sprintf(article, i)
+		}
+		return "[" + strings.Join(articles, ",") + "]"
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		wantError string
+	}{
+		{name: "malformed json", body: `[{"title": "Deployed", "link": }]`, wantError: "invalid json"},
+		{name: "wrong type", body: `{"title": ["Deployed"], "link": "https://ci.example.com/deploys/1"}`, wantError: "invalid json"},
+		{name: "empty batch", body: `[]`, wantError: "no articles submitted"},
+		{name: "oversized batch", body: batch(maxBatchSize + 1), wantError: "at most 100 articles"},
+		{name: "oversized request", body: `[{"title": "Deployed", "link": "https://ci.example.com/deploys/1", "summary": "` + strings.Repeat("a", maxRequestSize) + `"}]`, wantError: "too large"},
+		{name: "missing title", body: `[{"link": "https://ci.example.com/deploys/1"}]`, wantError: "article 0: title is required"},
+		{name: "relative link", body: `[{"title": "Deployed", "link": "https://ci.example.com/deploys/1"}, {"title": "Deployed", "link": "/deploys/2"}]`, wantError: "article 1: link must be"},
+		{name: "link of another scheme", body: `{"title": "Deployed", "link": "javascript:alert(1)"}`, wantError: "link must be"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			push := newMemoryPush()
+
+			status, resp := submit(t, push, tt.body)
+			if status != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", status, http.StatusBadRequest)
+			}
+			if msg, _ := resp["error"].(string); !strings.Contains(msg, tt.wantError) {
+				t.Errorf("error = %q, want it to contain %q", msg, tt.wantError)
+			}
+			if len(push.items) != 0 {
+				t.Errorf("ingested %d sources of a rejected batch, want none", len(push.items))
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "valid token", token: "t0ken", authorization: "Bearer t0ken", wantStatus: http.StatusNoContent},
+		{name: "missing token", token: "t0ken", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "t0ken", authorization: "Bearer guessed", wantStatus: http.StatusUnauthorized},
+		{name: "token without the scheme", token: "t0ken", authorization: "t0ken", wantStatus: http.StatusUnauthorized},
+		{name: "basic credentials", token: "t0ken", authorization: "Basic dDBrZW4=", wantStatus: http.StatusUnauthorized},
+		{name: "no token configured", authorization: "Bearer ", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireToken(tt.token, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/articles", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate header is missing")
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const (
+	defaultFailedLimit = 50
+	maxFailedLimit     = 500
+)
+
+type FailedArticleProvider interface {
+	FailedArticles(ctx context.Context, limit int) ([]model.Article, error)
+}
+
+type failedArticle struct {
+	ID          int64     `json:"id"`
+	SourceID    int64     `json:"source_id"`
+	SourceName  string    `json:"source_name"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	PublishedAt time.Time `json:"published_at"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error"`
+}
+
+// HandleFailedArticles lists the articles of the dead-letter queue, the ones
+// the notifier gave up on, newest first. The limit query parameter sets how
+// many, 50 by default.
+func HandleFailedArticles(provider FailedArticleProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultFailedLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				writeError(w, http.StatusBadRequest, errors.New("limit must be a positive number"))
+				return
+			}
+			limit = min(n, maxFailedLimit)
+		}
+
+		articles, err := provider.FailedArticles(r.Context(), limit)
+		if err != nil {
+			slog.Error("failed to get failed articles", "error", err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+			return
+		}
+
+		resp := make([]failedArticle, len(articles))
+		for i, article := range articles {
+			resp[i] = failedArticle{
+				ID:          article.ID,
+				SourceID:    article.SourceID,
+				SourceName:  article.SourceName,
+				Title:       article.Title,
+				Link:        article.Link,
+				PublishedAt: article.PublishedAt.UTC(),
+				Attempts:    article.Attempts,
+				LastError:   article.LastError,
+			}
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
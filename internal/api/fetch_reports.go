@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const (
+	defaultReportsLimit = 20
+	maxReportsLimit     = 500
+)
+
+type FetchReportProvider interface {
+	GetFetchReports(ctx context.Context, limit int) ([]model.FetchReport, error)
+}
+
+type fetchReport struct {
+	StartedAt        time.Time      `json:"started_at"`
+	DurationMS       int64          `json:"duration_ms"`
+	SourcesAttempted int            `json:"sources_attempted"`
+	SourcesSucceeded int            `json:"sources_succeeded"`
+	SourcesFailed    int            `json:"sources_failed"`
+	ItemsSeen        int            `json:"items_seen"`
+	ItemsInserted    int            `json:"items_inserted"`
+	ItemsDuplicate   int            `json:"items_duplicate"`
+	ItemsFiltered    int            `json:"items_filtered"`
+	Errors           map[string]int `json:"errors"`
+}
+
+// HandleFetchReports lists the reports of the latest fetcher runs, newest first.
+// The limit query parameter sets how many, 20 by default.
+func HandleFetchReports(provider FetchReportProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultReportsLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				writeError(w, http.StatusBadRequest, errors.New("limit must be a positive number"))
+				return
+			}
+			limit = min(n, maxReportsLimit)
+		}
+
+		reports, err := provider.GetFetchReports(r.Context(), limit)
+		if err != nil {
+			slog.Error("failed to get fetch reports", "error", err)
+			writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+			return
+		}
+
+		resp := make([]fetchReport, len(reports))
+		for i, report := range reports {
+			resp[i] = fetchReport{
+				StartedAt:        report.StartedAt.UTC(),
+				DurationMS:       report.Duration.Milliseconds(),
+				SourcesAttempted: report.SourcesAttempted,
+				SourcesSucceeded: report.SourcesSucceeded,
+				SourcesFailed:    report.SourcesFailed,
+				ItemsSeen:        report.ItemsSeen,
+				ItemsInserted:    report.ItemsInserted,
+				ItemsDuplicate:   report.ItemsDuplicate,
+				ItemsFiltered:    report.ItemsFiltered,
+				Errors:           report.Errors,
+			}
+		}
+
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit/markup"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// AdminAlerter sends private messages to the administrators of the channel.
+// Admins who never started a chat with the bot cannot be reached and are skipped.
+type AdminAlerter struct {
+	bot       *tgbotapi.BotAPI
+	channelID int64
+}
+
+func NewAdminAlerter(bot *tgbotapi.BotAPI, channelID int64) *AdminAlerter {
+	return &AdminAlerter{
+		bot:       bot,
+		channelID: channelID,
+	}
+}
+
+func (a *AdminAlerter) AlertSourceDisabled(ctx context.Context, source model.Source, reason string) error {
+	msgText := fmt.Sprintf(
+		"⚠️ Source *%s* \\(ID: `%d`\\) was disabled: %s\n\nUse /enablesource %d to enable it again\\.",
+		markup.EscapeForMarkdown(source.Name),
+		source.ID,
+		markup.EscapeForMarkdown(reason),
+		source.ID,
+	)
+
+	return a.alert(msgText)
+}
+
+func (a *AdminAlerter) alert(msgText string) error {
+	admins, err := a.bot.GetChatAdministrators(
+		tgbotapi.ChatAdministratorsConfig{
+			ChatConfig: tgbotapi.ChatConfig{
+				ChatID: a.channelID,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, admin := range admins {
+		if admin.User.IsBot {
+			continue
+		}
+
+		msg := tgbotapi.NewMessage(admin.User.ID, msgText)
+		msg.ParseMode = parseModeMarkdownV2
+
+		if _, err := a.bot.Send(msg); err != nil {
+			slog.Warn("failed to alert admin", "user_id", admin.User.ID, "error", err)
+		}
+	}
+
+	return nil
+}
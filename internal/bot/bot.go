@@ -1,3 +1,63 @@
 package bot
 
+import (
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
 const parseModeMarkdownV2 = "MarkdownV2"
+
+// maxMessageLength is the Telegram limit for the text of a message.
+const maxMessageLength = 4096
+
+// sendBlocks sends the MarkdownV2 header and blocks separated by blank lines,
+// split into as many messages as the Telegram limit requires. A block is
+// never split, so that its markup stays balanced.
+func sendBlocks(bot *tgbotapi.BotAPI, chatID int64, header string, blocks []string) error {
+	var b strings.Builder
+	b.WriteString(header)
+
+	flush := func() error {
+		if b.Len() == 0 {
+			return nil
+		}
+
+		reply := tgbotapi.NewMessage(chatID, b.String())
+		reply.ParseMode = parseModeMarkdownV2
+		b.Reset()
+
+		if _, err := bot.Send(reply); err != nil {
+			return err
+		}
+
+		return nil
+	}
+
+	for _, block := range blocks {
+		if b.Len() > 0 && utf8.RuneCountInString(b.String())+2+utf8.RuneCountInString(block) > maxMessageLength {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(block)
+	}
+
+	return flush()
+}
+
+// shorten cuts the text to at most limit runes, so that a long error (an HTML
+// error page, a stack of wrapped errors) cannot blow up a message.
+func shorten(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	return string(runes[:limit]) + "…"
+}
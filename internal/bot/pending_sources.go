@@ -0,0 +1,73 @@
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+	"github.com/ozaitsev92/gonewsbot/internal/source"
+)
+
+// pendingSource is a source waiting for the admin to answer an inline keyboard.
+type pendingSource struct {
+	source     model.Source
+	candidates []source.Candidate
+	expiresAt  time.Time
+}
+
+// PendingSources keeps the sources between a command and the button press
+// that completes it. Callback data is limited to 64 bytes, which is too
+// short for feed URLs, so the buttons only carry a token of the entry.
+type PendingSources struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]pendingSource
+}
+
+func NewPendingSources(ttl time.Duration) *PendingSources {
+	return &PendingSources{
+		ttl:     ttl,
+		entries: make(map[string]pendingSource),
+	}
+}
+
+func (p *PendingSources) put(entry pendingSource) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for token, e := range p.entries {
+		if now.After(e.expiresAt) {
+			delete(p.entries, token)
+		}
+	}
+
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	token := hex.EncodeToString(buf)
+
+	entry.expiresAt = now.Add(p.ttl)
+	p.entries[token] = entry
+
+	return token
+}
+
+// take removes the entry, so that every keyboard can be answered only once.
+func (p *PendingSources) take(token string) (pendingSource, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry, ok := p.entries[token]
+	if !ok {
+		return pendingSource{}, false
+	}
+	delete(p.entries, token)
+
+	if time.Now().After(entry.expiresAt) {
+		return pendingSource{}, false
+	}
+
+	return entry, true
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+	"github.com/ozaitsev92/gonewsbot/internal/filter"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+type FilterRuleStorage interface {
+	AddFilterRule(ctx context.Context, rule model.FilterRule) (int64, error)
+}
+
+// ViewCmdAddRule stores an include or exclude rule. Without a source id the rule applies to every source.
+func ViewCmdAddRule(storage FilterRuleStorage) botkit.ViewFunc {
+	type addRuleArgs struct {
+		Action    string          `json:"action"`
+		SourceID  int64           `json:"source_id"`
+		Condition json.RawMessage `json:"condition"`
+	}
+
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		args, err := botkit.ParseJSON[addRuleArgs](update.Message.CommandArguments())
+		if err != nil || len(args.Condition) == 0 {
+			return sendText(
+				bot,
+				update.Message.Chat.ID,
+				`Usage: /addrule {"action": "exclude", "source_id": 3, "condition": {"any": [{"field": "title", "match": "word", "value": "crypto"}, {"field": "domain", "match": "equals", "value": "example.com"}]}}`+
+					"\n\nActions: include, exclude. Fields: title, summary, category, author, domain, language. Matches: contains, word, regex, equals. Conditions combine with all, any and not.",
+			)
+		}
+
+		rule := model.FilterRule{
+			SourceID:  args.SourceID,
+			Action:    args.Action,
+			Condition: args.Condition,
+		}
+		if err := filter.Validate(rule); err != nil {
+			return sendText(bot, update.Message.Chat.ID, "Invalid rule: "+err.Error())
+		}
+
+		id, err := storage.AddFilterRule(ctx, rule)
+		if err != nil {
+			return err
+		}
+
+		return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Rule %d successfully added", id))
+	}
+}
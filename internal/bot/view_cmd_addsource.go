@@ -2,51 +2,106 @@ package bot
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit/markup"
+	"github.com/ozaitsev92/gonewsbot/internal/fetcher"
 	"github.com/ozaitsev92/gonewsbot/internal/model"
+	"github.com/ozaitsev92/gonewsbot/internal/source"
+)
+
+const (
+	// CallbackAddSource is the callback view name of the feed choice keyboard of /addsource.
+	CallbackAddSource = "addsource"
+	// CallbackConfirmSource is the callback view name of the confirmation keyboard of /addsource.
+	CallbackConfirmSource = "confirmsource"
 )
 
 type SourceStorage interface {
 	AddSource(ctx context.Context, source model.Source) (int64, error)
 }
 
-func ViewCmdAddSource(storage SourceStorage) botkit.ViewFunc {
+type FeedDiscoverer interface {
+	Discover(ctx context.Context, url string) ([]source.Candidate, error)
+}
+
+type SourcePreviewer interface {
+	Preview(ctx context.Context, src model.Source) (fetcher.Preview, error)
+}
+
+// ViewCmdAddSource validates the feed with a dry-run fetch and asks
+// for a confirmation before the source is saved.
+func ViewCmdAddSource(discoverer FeedDiscoverer, previewer SourcePreviewer, pending *PendingSources) botkit.ViewFunc {
 	type addSourceArgs struct {
-		Name     string `json:"name"`
-		URL      string `json:"url"`
-		Priority int    `json:"priority"`
+		Name     string          `json:"name"`
+		URL      string          `json:"url"`
+		Type     string          `json:"type"`
+		Category string          `json:"category"`
+		Priority int             `json:"priority"`
+		Options  json.RawMessage `json:"options"`
 	}
 
 	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
 		args, err := botkit.ParseJSON[addSourceArgs](update.Message.CommandArguments())
-		if err != nil {
-			return err
+		if err != nil || args.URL == "" {
+			return sendText(
+				bot,
+				update.Message.Chat.ID,
+				`Usage: /addsource {"name": "Example", "url": "https://example.com/feed", "priority": 0}`,
+			)
 		}
 
-		source := model.Source{
+		src := model.Source{
 			Name:     args.Name,
 			FeedURL:  args.URL,
+			Type:     args.Type,
+			Category: args.Category,
 			Priority: args.Priority,
+			Options:  args.Options,
+		}
+
+		if src.Type != "" {
+			if !source.IsKnownType(src.Type) {
+				return sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Unknown source type %q", src.Type))
+			}
+
+			return previewSource(ctx, bot, update.Message.Chat.ID, previewer, pending, src)
 		}
 
-		sourceID, err := storage.AddSource(ctx, source)
+		// Without an explicit type the URL may be a feed or a website
+		// that advertises its feeds, so let the discovery figure it out.
+		candidates, err := discoverer.Discover(ctx, src.FeedURL)
 		if err != nil {
-			slog.Error("failed to add source", "error", err)
-			return err
+			slog.Warn("feed discovery failed", "url", src.FeedURL, "error", err)
+			return sendText(bot, update.Message.Chat.ID, "No feed found at "+src.FeedURL+": "+err.Error())
 		}
 
-		msgText := fmt.Sprintf(
-			"Source added with ID: `%d`\\. Use this ID to update or delete the source\\.",
-			sourceID,
-		)
+		if len(candidates) == 1 {
+			src.FeedURL = candidates[0].URL
+			src.Type = candidates[0].Type
+			return previewSource(ctx, bot, update.Message.Chat.ID, previewer, pending, src)
+		}
+
+		token := pending.put(pendingSource{source: src, candidates: candidates})
 
-		reply := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
+		rows := make([][]tgbotapi.InlineKeyboardButton, len(candidates))
+		for i, candidate := range candidates {
+			rows[i] = tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("%s (%s, %d items)", candidate.URL, candidate.Type, candidate.ItemCount),
+				botkit.CallbackData(CallbackAddSource, token+":"+strconv.Itoa(i)),
+			))
+		}
 
-		reply.ParseMode = parseModeMarkdownV2
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, "Several feeds were found, choose the one to add:")
+		reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
 
 		if _, err := bot.Send(reply); err != nil {
 			return err
@@ -55,3 +110,167 @@ func ViewCmdAddSource(storage SourceStorage) botkit.ViewFunc {
 		return nil
 	}
 }
+
+// ViewCallbackAddSource previews the feed picked from the candidates offered by /addsource.
+func ViewCallbackAddSource(previewer SourcePreviewer, pending *PendingSources) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		if err := answerCallback(bot, update.CallbackQuery); err != nil {
+			return err
+		}
+
+		chatID := update.FromChat().ID
+
+		token, idxStr, _ := strings.Cut(botkit.CallbackPayload(update), ":")
+		entry, ok := pending.take(token)
+		if !ok {
+			return sendText(bot, chatID, "This choice has expired, run /addsource again")
+		}
+
+		idx, err := strconv.Atoi(idxStr)
+		if err != nil || idx < 0 || idx >= len(entry.candidates) {
+			return fmt.Errorf("invalid candidate index %q", idxStr)
+		}
+
+		src := entry.source
+		src.FeedURL = entry.candidates[idx].URL
+		src.Type = entry.candidates[idx].Type
+
+		return previewSource(ctx, bot, chatID, previewer, pending, src)
+	}
+}
+
+// ViewCallbackConfirmSource saves or drops the source previewed by /addsource.
+func ViewCallbackConfirmSource(storage SourceStorage, pending *PendingSources) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		if err := answerCallback(bot, update.CallbackQuery); err != nil {
+			return err
+		}
+
+		chatID := update.FromChat().ID
+
+		token, action, _ := strings.Cut(botkit.CallbackPayload(update), ":")
+		entry, ok := pending.take(token)
+		if !ok {
+			return sendText(bot, chatID, "This confirmation has expired, run /addsource again")
+		}
+
+		if action != "add" {
+			return sendText(bot, chatID, "Source was not added")
+		}
+
+		return addSource(ctx, bot, chatID, storage, entry.source)
+	}
+}
+
+// previewSource fetches the feed once and shows what it would bring in,
+// with buttons to add the source or cancel.
+func previewSource(
+	ctx context.Context,
+	bot *tgbotapi.BotAPI,
+	chatID int64,
+	previewer SourcePreviewer,
+	pending *PendingSources,
+	src model.Source,
+) error {
+	preview, err := previewer.Preview(ctx, src)
+	if err != nil {
+		slog.Warn("feed validation failed", "url", src.FeedURL, "error", err)
+		return sendText(bot, chatID, "Feed validation failed for "+src.FeedURL+": "+err.Error())
+	}
+
+	if preview.ItemCount == 0 {
+		return sendText(bot, chatID, "Feed "+src.FeedURL+" has no items, the source was not added")
+	}
+
+	title := preview.Title
+	if title == "" {
+		title = src.Name
+	}
+
+	newest := "unknown"
+	if !preview.Newest.IsZero() {
+		newest = preview.Newest.UTC().Format(time.DateTime) + " UTC"
+	}
+
+	msgText := fmt.Sprintf(
+		"📰 *%s*\nURL: %s\nType: `%s`\nItems: %d\nNewest item: %s\nDropped by filters: %d\n\nAdd this source?",
+		markup.EscapeForMarkdown(title),
+		markup.EscapeForMarkdown(src.FeedURL),
+		src.Type,
+		preview.ItemCount,
+		markup.EscapeForMarkdown(newest),
+		preview.Filtered,
+	)
+
+	token := pending.put(pendingSource{source: src})
+
+	reply := tgbotapi.NewMessage(chatID, msgText)
+	reply.ParseMode = parseModeMarkdownV2
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Add", botkit.CallbackData(CallbackConfirmSource, token+":add")),
+		tgbotapi.NewInlineKeyboardButtonData("❌ Cancel", botkit.CallbackData(CallbackConfirmSource, token+":cancel")),
+	))
+
+	if _, err := bot.Send(reply); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// answerCallback stops the loading indicator of the pressed button and
+// removes the keyboard, so that the choice cannot be made twice.
+func answerCallback(bot *tgbotapi.BotAPI, query *tgbotapi.CallbackQuery) error {
+	if _, err := bot.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		return err
+	}
+
+	if query.Message != nil {
+		removeKeyboard := tgbotapi.NewEditMessageReplyMarkup(
+			query.Message.Chat.ID,
+			query.Message.MessageID,
+			tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}},
+		)
+		if _, err := bot.Request(removeKeyboard); err != nil {
+			slog.Warn("failed to remove inline keyboard", "error", err)
+		}
+	}
+
+	return nil
+}
+
+func addSource(ctx context.Context, bot *tgbotapi.BotAPI, chatID int64, storage SourceStorage, src model.Source) error {
+	sourceID, err := storage.AddSource(ctx, src)
+	if errors.Is(err, model.ErrSourceExists) {
+		return sendText(bot, chatID, "Source "+src.FeedURL+" already exists")
+	}
+	if err != nil {
+		slog.Error("failed to add source", "error", err)
+		return err
+	}
+
+	msgText := fmt.Sprintf(
+		"Source %s of type `%s` added with ID: `%d`\\. Use this ID to update or delete the source\\.",
+		markup.EscapeForMarkdown(src.FeedURL),
+		src.Type,
+		sourceID,
+	)
+
+	reply := tgbotapi.NewMessage(chatID, msgText)
+
+	reply.ParseMode = parseModeMarkdownV2
+
+	if _, err := bot.Send(reply); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func sendText(bot *tgbotapi.BotAPI, chatID int64, text string) error {
+	if _, err := bot.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+)
+
+type SourceEnabler interface {
+	EnableSource(ctx context.Context, sourceID int64) error
+}
+
+func ViewCmdEnableSource(enabler SourceEnabler) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		idStr := update.Message.CommandArguments()
+
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		if err := enabler.EnableSource(ctx, id); err != nil {
+			return err
+		}
+
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Source successfully enabled")
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,35 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+	"github.com/ozaitsev92/gonewsbot/internal/opml"
+)
+
+func ViewCmdExportOPML(lister SourceLister) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		sources, err := lister.GetSources(ctx)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := opml.Write(&buf, "gonewsbot sources", sources); err != nil {
+			return err
+		}
+
+		reply := tgbotapi.NewDocument(update.Message.Chat.ID, tgbotapi.FileBytes{
+			Name:  "gonewsbot.opml",
+			Bytes: buf.Bytes(),
+		})
+
+		if _, err := bot.Send(reply); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit/markup"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const (
+	// CallbackFailedArticle is the callback view name of the keyboards of /failed.
+	CallbackFailedArticle = "failedarticle"
+
+	defaultFailedArticles = 10
+	maxFailedArticles     = 30
+)
+
+const (
+	failedActionRetry = "retry"
+	failedActionSkip  = "skip"
+	failedActionPost  = "post"
+)
+
+type FailedArticleStorage interface {
+	FailedArticles(ctx context.Context, limit int) ([]model.Article, error)
+	RetryFailedArticle(ctx context.Context, id int64) (bool, error)
+	SkipFailedArticle(ctx context.Context, id int64) (bool, error)
+}
+
+type ArticlePoster interface {
+	PostWithoutSummary(ctx context.Context, id int64) (bool, error)
+}
+
+// ViewCmdFailed lists the articles the notifier gave up on, /failed 20 shows 20 of them.
+// Every article comes with buttons to retry it, skip it or post it without a summary.
+func ViewCmdFailed(storage FailedArticleStorage) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		limit := defaultFailedArticles
+		if arg := strings.TrimSpace(update.Message.CommandArguments()); arg != "" {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n <= 0 {
+				return sendText(bot, update.Message.Chat.ID, "Usage: /failed [number of articles]")
+			}
+			limit = min(n, maxFailedArticles)
+		}
+
+		articles, err := storage.FailedArticles(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		if len(articles) == 0 {
+			return sendText(bot, update.Message.Chat.ID, "There are no failed articles")
+		}
+
+		for _, article := range articles {
+			payload := strconv.FormatInt(article.ID, 10)
+
+			reply := tgbotapi.NewMessage(update.Message.Chat.ID, formatFailedArticle(article))
+			reply.ParseMode = parseModeMarkdownV2
+			reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔁 Retry", botkit.CallbackData(CallbackFailedArticle, failedActionRetry+":"+payload)),
+				tgbotapi.NewInlineKeyboardButtonData("⏭ Skip", botkit.CallbackData(CallbackFailedArticle, failedActionSkip+":"+payload)),
+				tgbotapi.NewInlineKeyboardButtonData("📤 Post as is", botkit.CallbackData(CallbackFailedArticle, failedActionPost+":"+payload)),
+			))
+
+			if _, err := bot.Send(reply); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// ViewCallbackFailedArticle applies the button pressed under an article of /failed.
+func ViewCallbackFailedArticle(storage FailedArticleStorage, poster ArticlePoster) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		if err := answerCallback(bot, update.CallbackQuery); err != nil {
+			return err
+		}
+
+		chatID := update.FromChat().ID
+
+		action, idStr, _ := strings.Cut(botkit.CallbackPayload(update), ":")
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid article id %q", idStr)
+		}
+
+		var (
+			ok   bool
+			done string
+		)
+		switch action {
+		case failedActionRetry:
+			ok, err = storage.RetryFailedArticle(ctx, id)
+			done = fmt.Sprintf("Article %d is queued again", id)
+		case failedActionSkip:
+			ok, err = storage.SkipFailedArticle(ctx, id)
+			done = fmt.Sprintf("Article %d is skipped", id)
+		case failedActionPost:
+			ok, err = poster.PostWithoutSummary(ctx, id)
+			done = fmt.Sprintf("Article %d is posted without a summary", id)
+		default:
+			return fmt.Errorf("unknown failed article action %q", action)
+		}
+		if err != nil {
+			slog.Error("failed to handle failed article", "article_id", id, "action", action, "error", err)
+			return sendText(bot, chatID, fmt.Sprintf("Article %d: %s failed: %s", id, action, err))
+		}
+
+		if !ok {
+			return sendText(bot, chatID, fmt.Sprintf("Article %d is not failed anymore", id))
+		}
+
+		return sendText(bot, chatID, done)
+	}
+}
+
+func formatFailedArticle(article model.Article) string {
+	return fmt.Sprintf(
+		"🔴 *%s*\nID: `%d`\nSource: %s\nPublished: %s\nURL: %s\nAttempts: %d\nError: %s",
+		markup.EscapeForMarkdown(article.Title),
+		article.ID,
+		markup.EscapeForMarkdown(article.SourceName),
+		markup.EscapeForMarkdown(article.PublishedAt.UTC().Format(time.DateTime)+" UTC"),
+		markup.EscapeForMarkdown(article.Link),
+		article.Attempts,
+		markup.EscapeForMarkdown(shorten(article.LastError, maxShownErrorLength)),
+	)
+}
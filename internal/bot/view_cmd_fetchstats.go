@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit/markup"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const (
+	defaultFetchStatsRuns = 10
+	maxFetchStatsRuns     = 50
+)
+
+type FetchReportProvider interface {
+	GetFetchReports(ctx context.Context, limit int) ([]model.FetchReport, error)
+}
+
+// ViewCmdFetchStats shows the reports of the latest fetcher runs, /fetchstats 20 shows 20 of them.
+func ViewCmdFetchStats(provider FetchReportProvider) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		limit := defaultFetchStatsRuns
+		if arg := strings.TrimSpace(update.Message.CommandArguments()); arg != "" {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n <= 0 {
+				return sendText(bot, update.Message.Chat.ID, "Usage: /fetchstats [number of runs]")
+			}
+			limit = min(n, maxFetchStatsRuns)
+		}
+
+		reports, err := provider.GetFetchReports(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		if len(reports) == 0 {
+			return sendText(bot, update.Message.Chat.ID, "There are no fetch reports yet")
+		}
+
+		lines := make([]string, len(reports))
+		for i, report := range reports {
+			lines[i] = formatFetchReport(report)
+		}
+
+		msgText := fmt.Sprintf(
+			"Latest fetch runs \\(%d\\):\n\n%s",
+			len(reports),
+			strings.Join(lines, "\n\n"),
+		)
+
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
+		reply.ParseMode = parseModeMarkdownV2
+
+		if _, err := bot.Send(reply); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+func formatFetchReport(report model.FetchReport) string {
+	icon := "🟢"
+	if report.SourcesFailed > 0 {
+		icon = "🟠"
+	}
+
+	text := fmt.Sprintf(
+		"Sources: %d attempted, %d succeeded, %d failed\nItems: %d seen, %d inserted, %d duplicate, %d filtered",
+		report.SourcesAttempted,
+		report.SourcesSucceeded,
+		report.SourcesFailed,
+		report.ItemsSeen,
+		report.ItemsInserted,
+		report.ItemsDuplicate,
+		report.ItemsFiltered,
+	)
+
+	if len(report.Errors) > 0 {
+		classes := make([]string, 0, len(report.Errors))
+		for class := range report.Errors {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+
+		counts := make([]string, len(classes))
+		for i, class := range classes {
+			counts[i] = fmt.Sprintf("%s: %d", class, report.Errors[class])
+		}
+		text += "\nErrors: " + strings.Join(counts, ", ")
+	}
+
+	return fmt.Sprintf(
+		"%s *%s UTC* in %s\n%s",
+		icon,
+		markup.EscapeForMarkdown(report.StartedAt.UTC().Format(time.DateTime)),
+		markup.EscapeForMarkdown(report.Duration.Round(time.Millisecond).String()),
+		markup.EscapeForMarkdown(text),
+	)
+}
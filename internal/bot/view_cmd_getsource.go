@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/ozaitsev92/gonewsbot/internal/botkit"
@@ -41,11 +43,53 @@ func ViewCmdGetSource(provider SourceProvider) botkit.ViewFunc {
 }
 
 func formatSource(source model.Source) string {
-	return fmt.Sprintf(
-		"🌐 *%s*\nID: `%d`\nFeed URL: %s\nPriority: %d",
+	text := fmt.Sprintf(
+		"🌐 *%s*\nID: `%d`\nFeed URL: %s\nType: `%s`\nPriority: %d\n%s",
 		markup.EscapeForMarkdown(source.Name),
 		source.ID,
 		markup.EscapeForMarkdown(source.FeedURL),
+		source.Type,
 		source.Priority,
+		formatSchedule(source),
+	)
+
+	if source.Category != "" {
+		text += "\nCategory: " + markup.EscapeForMarkdown(source.Category)
+	}
+
+	if len(source.Languages) > 0 {
+		text += "\nLanguages: " + markup.EscapeForMarkdown(strings.Join(source.Languages, ", "))
+	}
+
+	if !source.Enabled {
+		text += "\nDisabled: " + markup.EscapeForMarkdown(source.DisabledReason)
+	}
+
+	if source.LastError != "" {
+		text += fmt.Sprintf(
+			"\nFailures in a row: %d\nLast error: %s",
+			source.ConsecutiveFailures,
+			markup.EscapeForMarkdown(source.LastError),
+		)
+	}
+
+	return text
+}
+
+func formatSchedule(source model.Source) string {
+	interval := "default"
+	if source.FetchInterval > 0 {
+		interval = source.FetchInterval.String()
+	}
+
+	nextFetch := "as soon as possible"
+	if !source.NextFetchAt.IsZero() {
+		nextFetch = source.NextFetchAt.UTC().Format(time.DateTime) + " UTC"
+	}
+
+	return fmt.Sprintf(
+		"Fetch interval: %s\nNext fetch: %s",
+		markup.EscapeForMarkdown(interval),
+		markup.EscapeForMarkdown(nextFetch),
 	)
 }
@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit/markup"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const (
+	// healthWindow is the period the run statistics of /health are computed for.
+	healthWindow = 24 * time.Hour
+	// maxShownErrorLength keeps the long errors, like HTML error pages, readable in the chat.
+	maxShownErrorLength = 300
+)
+
+type SourceHealthProvider interface {
+	GetSourcesHealth(ctx context.Context, since time.Time) ([]model.SourceHealth, error)
+}
+
+// ViewCmdHealth lists the disabled and failing sources, /health all lists every source.
+func ViewCmdHealth(provider SourceHealthProvider) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		showAll := false
+		switch strings.TrimSpace(update.Message.CommandArguments()) {
+		case "":
+		case "all":
+			showAll = true
+		default:
+			return sendText(bot, update.Message.Chat.ID, "Usage: /health [all]")
+		}
+
+		healths, err := provider.GetSourcesHealth(ctx, time.Now().Add(-healthWindow))
+		if err != nil {
+			return err
+		}
+
+		disabled, failing := 0, 0
+		lines := make([]string, 0, len(healths))
+		for _, health := range healths {
+			healthy := true
+			switch {
+			case !health.Source.Enabled:
+				disabled++
+				healthy = false
+			case health.Source.ConsecutiveFailures > 0:
+				failing++
+				healthy = false
+			}
+
+			if showAll || !healthy {
+				lines = append(lines, formatSourceHealth(health))
+			}
+		}
+
+		header := fmt.Sprintf(
+			"Sources health for the last 24 hours \\(total %d, disabled %d, failing %d\\):",
+			len(healths),
+			disabled,
+			failing,
+		)
+		if len(lines) == 0 {
+			header += "\n\nAll sources are healthy"
+		}
+
+		return sendBlocks(bot, update.Message.Chat.ID, header, lines)
+	}
+}
+
+func formatSourceHealth(health model.SourceHealth) string {
+	icon := "🟢"
+	switch {
+	case !health.Source.Enabled:
+		icon = "⛔"
+	case health.Source.ConsecutiveFailures > 0:
+		icon = "🟠"
+	}
+
+	text := fmt.Sprintf(
+		"%s *%s* \\(ID: `%d`\\)\nRuns: %d, failed: %d",
+		icon,
+		markup.EscapeForMarkdown(health.Source.Name),
+		health.Source.ID,
+		health.RunsTotal,
+		health.RunsFailed,
+	)
+
+	if run := health.LastRun; run != nil {
+		text += markup.EscapeForMarkdown(fmt.Sprintf(
+			"\nLast run: %s at %s UTC, HTTP %d, %d items (%d new) in %s",
+			run.Status,
+			run.StartedAt.UTC().Format(time.DateTime),
+			run.HTTPStatus,
+			run.ItemCount,
+			run.NewItemCount,
+			run.Duration.Round(time.Millisecond),
+		))
+		if run.ErrorClass != "" {
+			text += markup.EscapeForMarkdown(", error: " + run.ErrorClass)
+		}
+	}
+
+	if !health.Source.Enabled {
+		text += "\nDisabled: " + markup.EscapeForMarkdown(shorten(health.Source.DisabledReason, maxShownErrorLength))
+	} else if health.Source.LastError != "" {
+		text += "\nLast error: " + markup.EscapeForMarkdown(shorten(health.Source.LastError, maxShownErrorLength))
+	}
+
+	return text
+}
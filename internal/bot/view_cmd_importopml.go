@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit/markup"
+	"github.com/ozaitsev92/gonewsbot/internal/opml"
+)
+
+// maxOPMLSize caps the size of an uploaded OPML document.
+const maxOPMLSize = 5 << 20
+
+// ViewCmdImportOPML imports the OPML document sent with the command as a
+// caption or the one the command replies to. The outlines with a fetch
+// interval longer than publishWindow fail, like in /setfetchinterval.
+func ViewCmdImportOPML(storage opml.SourceStorage, client *http.Client, publishWindow time.Duration) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		document := update.Message.Document
+		if document == nil && update.Message.ReplyToMessage != nil {
+			document = update.Message.ReplyToMessage.Document
+		}
+
+		if document == nil {
+			reply := tgbotapi.NewMessage(
+				update.Message.Chat.ID,
+				"Send an OPML file with /importopml as the caption or reply to the file with /importopml",
+			)
+			if _, err := bot.Send(reply); err != nil {
+				return err
+			}
+			return nil
+		}
+
+		if document.FileSize > maxOPMLSize {
+			return fmt.Errorf("opml file is too large: %d bytes", document.FileSize)
+		}
+
+		fileURL, err := bot.GetFileDirectURL(document.FileID)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("unexpected status code %d while downloading opml file", resp.StatusCode)
+		}
+
+		sources, err := opml.Parse(io.LimitReader(resp.Body, maxOPMLSize))
+		if err != nil {
+			reply := tgbotapi.NewMessage(update.Message.Chat.ID, "Failed to parse OPML: "+err.Error())
+			if _, err := bot.Send(reply); err != nil {
+				return err
+			}
+			return nil
+		}
+
+		var rejected []error
+		accepted := sources[:0]
+		for _, source := range sources {
+			if err := checkFetchInterval(source.FetchInterval, publishWindow); err != nil {
+				rejected = append(rejected, fmt.Errorf("%s: %w", source.FeedURL, err))
+				continue
+			}
+			accepted = append(accepted, source)
+		}
+
+		result, err := opml.Import(ctx, storage, accepted)
+		if err != nil {
+			return err
+		}
+		result.Failed += len(rejected)
+		result.Errors = append(rejected, result.Errors...)
+
+		header := markup.EscapeForMarkdown(fmt.Sprintf(
+			"Imported %d sources, skipped %d duplicates, failed %d",
+			result.Added,
+			result.Skipped,
+			result.Failed,
+		))
+
+		// A large document can fail many outlines, the report is split over as many messages as needed.
+		blocks := make([]string, len(result.Errors))
+		for i, err := range result.Errors {
+			blocks[i] = markup.EscapeForMarkdown(shorten(err.Error(), maxShownErrorLength))
+		}
+
+		if err := sendBlocks(bot, update.Message.Chat.ID, header, blocks); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
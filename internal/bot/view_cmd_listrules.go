@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit/markup"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+type FilterRuleLister interface {
+	GetFilterRules(ctx context.Context) ([]model.FilterRule, error)
+}
+
+func ViewCmdListRules(lister FilterRuleLister) botkit.ViewFunc {
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		rules, err := lister.GetFilterRules(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(rules) == 0 {
+			return sendText(bot, update.Message.Chat.ID, "There are no filter rules")
+		}
+
+		ruleInfos := make([]string, len(rules))
+		for i, rule := range rules {
+			ruleInfos[i] = formatRule(rule)
+		}
+
+		msgText := fmt.Sprintf(
+			"List of filter rules \\(total %d\\):\n\n%s",
+			len(rules),
+			strings.Join(ruleInfos, "\n\n"),
+		)
+
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
+		reply.ParseMode = parseModeMarkdownV2
+
+		if _, err := bot.Send(reply); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+func formatRule(rule model.FilterRule) string {
+	scope := "all sources"
+	if rule.SourceID != 0 {
+		scope = fmt.Sprintf("source %d", rule.SourceID)
+	}
+
+	return fmt.Sprintf(
+		"ID: `%d`\nAction: *%s*\nScope: %s\nCondition: %s",
+		rule.ID,
+		rule.Action,
+		scope,
+		markup.Code(string(rule.Condition)),
+	)
+}
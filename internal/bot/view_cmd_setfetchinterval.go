@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+)
+
+type FetchIntervalSetter interface {
+	SetFetchInterval(ctx context.Context, sourceID int64, interval time.Duration) error
+}
+
+// ViewCmdSetFetchInterval sets the fetch interval of a source. It cannot be
+// longer than publishWindow, the age up to which the notifier posts articles.
+func ViewCmdSetFetchInterval(setter FetchIntervalSetter, publishWindow time.Duration) botkit.ViewFunc {
+	type setFetchIntervalArgs struct {
+		SourceID int64  `json:"source_id"`
+		Interval string `json:"interval"`
+	}
+
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		args, err := botkit.ParseJSON[setFetchIntervalArgs](update.Message.CommandArguments())
+		if err != nil {
+			return err
+		}
+
+		// An empty interval resets the source to the global fetch interval.
+		var interval time.Duration
+		if args.Interval != "" {
+			interval, err = time.ParseDuration(args.Interval)
+			if err != nil {
+				return err
+			}
+			if interval < time.Minute {
+				return fmt.Errorf("fetch interval %s is shorter than a minute", interval)
+			}
+			if err := checkFetchInterval(interval, publishWindow); err != nil {
+				return err
+			}
+		}
+
+		if err := setter.SetFetchInterval(ctx, args.SourceID, interval); err != nil {
+			return err
+		}
+
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Fetch interval successfully updated")
+
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// checkFetchInterval rejects the intervals longer than the publish window:
+// the articles a source gets would be too old to be posted by the time they are fetched.
+func checkFetchInterval(interval time.Duration, publishWindow time.Duration) error {
+	if interval > publishWindow {
+		return fmt.Errorf("fetch interval %s is longer than the publish window of %s, the articles of the source would never be posted", interval, publishWindow)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+)
+
+type LanguagesSetter interface {
+	SetLanguages(ctx context.Context, sourceID int64, languages []string) error
+}
+
+// ViewCmdSetLanguages sets the ISO 639-1 codes of the languages allowed for a source.
+func ViewCmdSetLanguages(setter LanguagesSetter) botkit.ViewFunc {
+	type setLanguagesArgs struct {
+		SourceID  int64    `json:"source_id"`
+		Languages []string `json:"languages"`
+	}
+
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		args, err := botkit.ParseJSON[setLanguagesArgs](update.Message.CommandArguments())
+		if err != nil {
+			return err
+		}
+
+		// No languages reset the source to the global list.
+		languages := make([]string, 0, len(args.Languages))
+		for _, language := range args.Languages {
+			language = strings.ToLower(strings.TrimSpace(language))
+			if len(language) != 2 {
+				return fmt.Errorf("%q is not an ISO 639-1 language code", language)
+			}
+			languages = append(languages, language)
+		}
+
+		if err := setter.SetLanguages(ctx, args.SourceID, languages); err != nil {
+			return err
+		}
+
+		msg := tgbotapi.NewMessage(update.Message.Chat.ID, "Languages successfully updated")
+
+		if _, err := bot.Send(msg); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
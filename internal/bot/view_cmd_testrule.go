@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit/markup"
+	"github.com/ozaitsev92/gonewsbot/internal/filter"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// testRuleMaxItems is how many matched items /testrule shows.
+const testRuleMaxItems = 5
+
+type FilterSetLoader interface {
+	Load(ctx context.Context) (*filter.Set, error)
+}
+
+// ViewCmdTestRule does a dry-run fetch of a source and shows which items a
+// condition, a stored rule or, without either, the current rules would drop.
+func ViewCmdTestRule(sources SourceProvider, rules FilterRuleLister, filters FilterSetLoader, previewer ItemsPreviewer) botkit.ViewFunc {
+	type testRuleArgs struct {
+		SourceID  int64           `json:"source_id"`
+		RuleID    int64           `json:"rule_id"`
+		Condition json.RawMessage `json:"condition"`
+	}
+
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		args, err := botkit.ParseJSON[testRuleArgs](update.Message.CommandArguments())
+		if err != nil || args.SourceID == 0 {
+			return sendText(
+				bot,
+				update.Message.Chat.ID,
+				`Usage: /testrule {"source_id": 3, "condition": {"field": "title", "match": "regex", "value": "(?i)^ask hn"}}`+
+					"\n"+`or /testrule {"source_id": 3, "rule_id": 5} to test a stored rule, or /testrule {"source_id": 3} to test all current rules`,
+			)
+		}
+
+		matches, description, err := testedMatcher(ctx, args.SourceID, args.RuleID, args.Condition, rules, filters)
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, "Rule test failed: "+err.Error())
+		}
+
+		src, err := sources.GetSourceByID(ctx, args.SourceID)
+		if err != nil {
+			return err
+		}
+
+		items, err := previewer.PreviewItems(ctx, *src)
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, "Rule test failed: "+err.Error())
+		}
+
+		var matched []model.Item
+		for _, item := range items {
+			if matches(item) {
+				matched = append(matched, item)
+			}
+		}
+
+		shown := matched[:min(len(matched), testRuleMaxItems)]
+		lines := make([]string, len(shown))
+		for i, item := range shown {
+			lines[i] = fmt.Sprintf(
+				"• %s",
+				markup.Link(item.Title, item.Link),
+			)
+		}
+
+		msgText := fmt.Sprintf(
+			"%s %d of %d items of *%s*",
+			markup.EscapeForMarkdown(description),
+			len(matched),
+			len(items),
+			markup.EscapeForMarkdown(src.Name),
+		)
+		if len(lines) > 0 {
+			msgText += ":\n\n" + strings.Join(lines, "\n")
+		}
+
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
+		reply.ParseMode = parseModeMarkdownV2
+		reply.DisableWebPagePreview = true
+
+		if _, err := bot.Send(reply); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// testedMatcher returns the predicate under test and how to describe its matches.
+func testedMatcher(
+	ctx context.Context,
+	sourceID int64,
+	ruleID int64,
+	condition json.RawMessage,
+	rules FilterRuleLister,
+	filters FilterSetLoader,
+) (filter.Predicate, string, error) {
+	switch {
+	case len(condition) > 0:
+		matches, err := filter.Parse(condition)
+		return matches, "The condition matches", err
+	case ruleID != 0:
+		stored, err := rules.GetFilterRules(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, rule := range stored {
+			if rule.ID == ruleID {
+				matches, err := filter.Parse(rule.Condition)
+				return matches, fmt.Sprintf("Rule %d (%s) matches", rule.ID, rule.Action), err
+			}
+		}
+		return nil, "", fmt.Errorf("rule %d not found", ruleID)
+	default:
+		set, err := filters.Load(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		return func(item model.Item) bool {
+			return !set.Allows(sourceID, item)
+		}, "The current rules drop", nil
+	}
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit"
+	"github.com/ozaitsev92/gonewsbot/internal/botkit/markup"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// testSelectorsMaxItems is how many scraped items /testselectors shows.
+const testSelectorsMaxItems = 5
+
+type ItemsPreviewer interface {
+	PreviewItems(ctx context.Context, src model.Source) ([]model.Item, error)
+}
+
+// ViewCmdTestSelectors scrapes a page with the given CSS selectors and shows
+// the first items, so that the selectors can be tuned before /addsource.
+func ViewCmdTestSelectors(previewer ItemsPreviewer) botkit.ViewFunc {
+	type testSelectorsArgs struct {
+		URL     string          `json:"url"`
+		Options json.RawMessage `json:"options"`
+	}
+
+	return func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error {
+		args, err := botkit.ParseJSON[testSelectorsArgs](update.Message.CommandArguments())
+		if err != nil || args.URL == "" {
+			return sendText(
+				bot,
+				update.Message.Chat.ID,
+				`Usage: /testselectors {"url": "https://example.com/news", "options": {"item": "article", "title": "h2", "link": "h2 a", "date": "time", "summary": "p"}}`,
+			)
+		}
+
+		items, err := previewer.PreviewItems(ctx, model.Source{
+			Name:    args.URL,
+			FeedURL: args.URL,
+			Type:    model.SourceTypeHTML,
+			Options: args.Options,
+		})
+		if err != nil {
+			return sendText(bot, update.Message.Chat.ID, "Selectors test failed: "+err.Error())
+		}
+
+		shown := items[:min(len(items), testSelectorsMaxItems)]
+		lines := make([]string, len(shown))
+		for i, item := range shown {
+			date := "no date"
+			if !item.Date.IsZero() {
+				date = item.Date.UTC().Format(time.DateTime)
+			}
+
+			lines[i] = fmt.Sprintf(
+				"*%s*\n%s\n_%s_\n%s",
+				markup.EscapeForMarkdown(item.Title),
+				markup.EscapeForMarkdown(item.Link),
+				markup.EscapeForMarkdown(date),
+				markup.EscapeForMarkdown(truncate(item.Summary, 200)),
+			)
+		}
+
+		msgText := fmt.Sprintf(
+			"Selectors matched %d items, the first %d:\n\n%s",
+			len(items),
+			len(shown),
+			strings.Join(lines, "\n\n"),
+		)
+
+		reply := tgbotapi.NewMessage(update.Message.Chat.ID, msgText)
+		reply.ParseMode = parseModeMarkdownV2
+
+		if _, err := bot.Send(reply); err != nil {
+			return err
+		}
+
+		return nil
+	}
+}
+
+func truncate(text string, maxRunes int) string {
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	return string(runes[:maxRunes]) + "…"
+}
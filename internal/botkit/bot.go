@@ -9,9 +9,14 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// updatesRetryDelay is the pause after a failed poll, e.g. while another
+// instance still finishes its last poll.
+const updatesRetryDelay = 3 * time.Second
+
 type Bot struct {
-	api      *tgbotapi.BotAPI
-	cmdViews map[string]ViewFunc
+	api           *tgbotapi.BotAPI
+	cmdViews      map[string]ViewFunc
+	callbackViews map[string]ViewFunc
 }
 
 func New(api *tgbotapi.BotAPI) *Bot {
@@ -26,20 +31,49 @@ func (b *Bot) RegisterCmdView(cmd string, view ViewFunc) {
 	b.cmdViews[cmd] = view
 }
 
+// RegisterCallbackView registers a view for the inline keyboard buttons
+// whose callback data was built with CallbackData for the given name.
+func (b *Bot) RegisterCallbackView(name string, view ViewFunc) {
+	if b.callbackViews == nil {
+		b.callbackViews = make(map[string]ViewFunc)
+	}
+
+	b.callbackViews[name] = view
+}
+
+// Run polls the updates until the context is cancelled. Unlike GetUpdatesChan,
+// the polling can be started again after it was stopped.
 func (b *Bot) Run(ctx context.Context) error {
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
-	updates := b.api.GetUpdatesChan(u)
-
 	for {
-		select {
-		case update := <-updates:
+		updates, err := b.api.GetUpdates(u)
+		if ctx.Err() != nil {
+			// The updates are confirmed by the next poll only, so the
+			// updates of this one are left to the next instance.
+			return ctx.Err()
+		}
+		if err != nil {
+			slog.Error("failed to get updates", "error", err)
+
+			select {
+			case <-time.After(updatesRetryDelay):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		for _, update := range updates {
+			if update.UpdateID < u.Offset {
+				continue
+			}
+			u.Offset = update.UpdateID + 1
+
 			updateCtx, updateCancel := context.WithTimeout(context.Background(), 5*time.Minute)
 			b.handleUpdate(updateCtx, update)
 			updateCancel()
-		case <-ctx.Done():
-			return ctx.Err()
 		}
 	}
 }
@@ -51,32 +85,49 @@ func (b *Bot) handleUpdate(ctx context.Context, update tgbotapi.Update) {
 		}
 	}()
 
-	if (update.Message == nil || !update.Message.IsCommand()) && update.CallbackQuery == nil {
-		return
+	if update.Message != nil {
+		promoteCaptionCommand(update.Message)
 	}
 
-	var view ViewFunc
-
-	if !update.Message.IsCommand() {
-		return
+	var (
+		view ViewFunc
+		name string
+	)
+
+	switch {
+	case update.CallbackQuery != nil:
+		name, _ = parseCallbackData(update.CallbackQuery.Data)
+		view = b.callbackViews[name]
+	case update.Message != nil && update.Message.IsCommand():
+		name = update.Message.Command()
+		view = b.cmdViews[name]
 	}
 
-	cmd := update.Message.Command()
-
-	cmdView, ok := b.cmdViews[cmd]
-	if !ok {
+	if view == nil {
 		return
 	}
 
-	view = cmdView
-
 	if err := view(ctx, b.api, update); err != nil {
-		slog.Error("failed to handle command", "command", cmd, "error", err)
+		slog.Error("failed to handle update", "view", name, "error", err)
 
-		if _, err := b.api.Send(tgbotapi.NewMessage(update.Message.Chat.ID, "Internal error")); err != nil {
-			slog.Error("failed to send error message", "chat_id", update.Message.Chat.ID, "error", err)
+		chatID := update.FromChat().ID
+		if _, err := b.api.Send(tgbotapi.NewMessage(chatID, "Internal error")); err != nil {
+			slog.Error("failed to send error message", "chat_id", chatID, "error", err)
 		}
 	}
 }
 
+// promoteCaptionCommand lets a command be sent as the caption of a file:
+// the caption is moved to the text, so that the command helpers of the message work.
+func promoteCaptionCommand(msg *tgbotapi.Message) {
+	if msg.Text != "" || len(msg.CaptionEntities) == 0 {
+		return
+	}
+
+	if entity := msg.CaptionEntities[0]; entity.Offset == 0 && entity.IsCommand() {
+		msg.Text = msg.Caption
+		msg.Entities = msg.CaptionEntities
+	}
+}
+
 type ViewFunc func(ctx context.Context, bot *tgbotapi.BotAPI, update tgbotapi.Update) error
@@ -0,0 +1,29 @@
+package botkit
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// CallbackData builds the callback data of an inline keyboard button
+// handled by the callback view registered under the name.
+// Telegram limits callback data to 64 bytes.
+func CallbackData(name string, payload string) string {
+	return name + ":" + payload
+}
+
+// CallbackPayload returns the payload of the callback query of the update.
+func CallbackPayload(update tgbotapi.Update) string {
+	if update.CallbackQuery == nil {
+		return ""
+	}
+
+	_, payload := parseCallbackData(update.CallbackQuery.Data)
+	return payload
+}
+
+func parseCallbackData(data string) (string, string) {
+	name, payload, _ := strings.Cut(data, ":")
+	return name, payload
+}
@@ -44,3 +44,27 @@ var replacer = strings.NewReplacer(
 func EscapeForMarkdown(src string) string {
 	return replacer.Replace(src)
 }
+
+var linkURLReplacer = strings.NewReplacer(
+	"\\",
+	"\\\\",
+	")",
+	"\\)",
+)
+
+// Link renders an inline link, the URL part only needs ) and \ escaped.
+func Link(text string, url string) string {
+	return "[" + EscapeForMarkdown(text) + "](" + linkURLReplacer.Replace(url) + ")"
+}
+
+var codeReplacer = strings.NewReplacer(
+	"\\",
+	"\\\\",
+	"`",
+	"\\`",
+)
+
+// Code renders inline code, inside it only ` and \ need to be escaped.
+func Code(text string) string {
+	return "`" + codeReplacer.Replace(text) + "`"
+}
@@ -0,0 +1,215 @@
+package canonical
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ozaitsev92/gonewsbot/internal/htmlutil"
+	"golang.org/x/net/html"
+)
+
+// trackingParams are query parameters that identify the campaign or the
+// referrer of a click and never change the content of the page. Generic names
+// like "ref" or "source" are left alone, some sites serve other content for them
+// (GitHub picks the branch with ?ref=).
+var trackingParams = map[string]struct{}{
+	"ref_src":     {},
+	"ref_url":     {},
+	"fbclid":      {},
+	"gclid":       {},
+	"dclid":       {},
+	"msclkid":     {},
+	"yclid":       {},
+	"igshid":      {},
+	"twclid":      {},
+	"mc_cid":      {},
+	"mc_eid":      {},
+	"mkt_tok":     {},
+	"_hsenc":      {},
+	"_hsmi":       {},
+	"cmpid":       {},
+	"spm":         {},
+	"amp":         {},
+	"outputtype":  {},
+	"oly_anon_id": {},
+	"oly_enc_id":  {},
+	"vero_id":     {},
+}
+
+// trackingPrefixes are prefixes of whole families of tracking parameters.
+var trackingPrefixes = []string{"utm_", "pk_", "mtm_", "__s", "hmb_"}
+
+// URL normalizes a link so that the variants of the same page compare equal:
+// the scheme and host are lower case, default ports, fragments, tracking
+// parameters and trailing slashes are dropped, the remaining query parameters
+// are sorted and the AMP cache and viewer URLs are mapped back to the page of
+// the publisher. Other AMP versions are left alone, "amp." hosts and "/amp"
+// paths are real pages on some sites, their canonical link names the regular page.
+// Links that do not parse are returned unchanged.
+func URL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = u.Hostname()
+	}
+
+	u = unwrapAMPCache(u)
+
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+
+	u.RawQuery = cleanQuery(u.Query())
+
+	return u.String()
+}
+
+// unwrapAMPCache maps the Google AMP viewer and the AMP cache URLs
+// (https://www.google.com/amp/s/example.com/a, https://example-com.cdn.ampproject.org/c/s/example.com/a)
+// to the URL of the publisher.
+func unwrapAMPCache(u *url.URL) *url.URL {
+	var rest string
+	switch {
+	case strings.HasPrefix(u.Host, "www.google.") && strings.HasPrefix(u.Path, "/amp/"):
+		rest = strings.TrimPrefix(u.Path, "/amp/")
+	case strings.HasSuffix(u.Host, ".cdn.ampproject.org"):
+		// The path starts with the content type (/c, /v, /i) of the cached document.
+		_, rest, _ = strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	default:
+		return u
+	}
+
+	scheme := "http"
+	if after, ok := strings.CutPrefix(rest, "s/"); ok {
+		scheme = "https"
+		rest = after
+	}
+
+	unwrapped, err := url.Parse(scheme + "://" + rest)
+	if err != nil || unwrapped.Host == "" {
+		return u
+	}
+	unwrapped.RawQuery = u.RawQuery
+	unwrapped.Host = strings.ToLower(unwrapped.Host)
+
+	return unwrapped
+}
+
+func cleanQuery(query url.Values) string {
+	for key := range query {
+		if isTrackingParam(key) {
+			delete(query, key)
+		}
+	}
+
+	if len(query) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(key))
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(value))
+		}
+	}
+
+	return b.String()
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	if _, ok := trackingParams[key]; ok {
+		return true
+	}
+
+	for _, prefix := range trackingPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LinkFromHTML returns the absolute <link rel="canonical"> URL of an HTML
+// document or fragment, resolved against base. It is empty when there is none.
+func LinkFromHTML(document string, base string) string {
+	if !strings.Contains(strings.ToLower(document), "canonical") {
+		return ""
+	}
+
+	root, err := html.Parse(strings.NewReader(document))
+	if err != nil {
+		return ""
+	}
+
+	var href string
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if href != "" {
+			return
+		}
+
+		if node.Type == html.ElementNode && node.Data == "link" {
+			var rel, value string
+			for _, attr := range node.Attr {
+				switch strings.ToLower(attr.Key) {
+				case "rel":
+					rel = strings.ToLower(attr.Val)
+				case "href":
+					value = strings.TrimSpace(attr.Val)
+				}
+			}
+			if value != "" && htmlutil.HasToken(rel, "canonical") {
+				href = value
+				return
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	if href == "" {
+		return ""
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ""
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+
+	resolved := baseURL.ResolveReference(ref)
+	if resolved.Scheme != "http" && resolved.Scheme != "https" {
+		return ""
+	}
+
+	return resolved.String()
+}
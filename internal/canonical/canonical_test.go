@@ -0,0 +1,93 @@
+package canonical
+
+import "testing"
+
+func TestURL(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "utm parameters",
+			raw:  "https://example.com/post?utm_source=rss&utm_medium=feed&id=7",
+			want: "https://example.com/post?id=7",
+		},
+		{
+			name: "click ids",
+			raw:  "https://example.com/post?fbclid=abc&gclid=def",
+			want: "https://example.com/post",
+		},
+		{
+			name: "github ref picks the branch",
+			raw:  "https://github.com/golang/go/blob/README.md?ref=release-branch.go1.24",
+			want: "https://github.com/golang/go/blob/README.md?ref=release-branch.go1.24",
+		},
+		{
+			name: "generic parameters are kept",
+			raw:  "https://example.com/search?source=web&share=1&rss=1",
+			want: "https://example.com/search?rss=1&share=1&source=web",
+		},
+		{
+			name: "case, port, fragment and trailing slash",
+			raw:  "HTTPS://Example.COM:443/post/#comments",
+			want: "https://example.com/post",
+		},
+		{
+			name: "amp cache",
+			raw:  "https://example-com.cdn.ampproject.org/c/s/example.com/post",
+			want: "https://example.com/post",
+		},
+		{
+			name: "amp cache over http",
+			raw:  "https://example-com.cdn.ampproject.org/v/example.com/post?utm_source=amp",
+			want: "http://example.com/post",
+		},
+		{
+			name: "google amp viewer",
+			raw:  "https://www.google.com/amp/s/example.com/post/",
+			want: "https://example.com/post",
+		},
+		{
+			name: "amp host is a site of its own",
+			raw:  "https://amp.dev/documentation/guides-and-tutorials/",
+			want: "https://amp.dev/documentation/guides-and-tutorials",
+		},
+		{
+			name: "amp path is a page of its own",
+			raw:  "https://example.com/tags/amp",
+			want: "https://example.com/tags/amp",
+		},
+		{
+			name: "amp extension is kept",
+			raw:  "https://example.com/files/model.amp",
+			want: "https://example.com/files/model.amp",
+		},
+		{
+			name: "amp path outside the viewer",
+			raw:  "https://www.google.com/maps/amp/place",
+			want: "https://www.google.com/maps/amp/place",
+		},
+		{
+			name: "unparsable link",
+			raw:  "not a link",
+			want: "not a link",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := URL(tt.raw); got != tt.want {
+				t.Errorf("URL(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinkFromHTML(t *testing.T) {
+	document := `<html><head><link rel="stylesheet" href="/style.css"><link rel="canonical" href="/post/1"></head><body></body></html>`
+
+	if got := LinkFromHTML(document, "https://example.com/amp/post/1"); got != "https://example.com/post/1" {
+		t.Errorf("LinkFromHTML() = %q, want the canonical link", got)
+	}
+}
@@ -0,0 +1,82 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+type FingerprintStorage interface {
+	RecentFingerprints(ctx context.Context, since time.Time) ([]model.Fingerprint, error)
+	// AssignCluster stores the hash of the article and puts it into the cluster.
+	// A clusterID of 0 starts a new cluster with the article.
+	AssignCluster(ctx context.Context, articleID int64, simHash uint64, clusterID int64) (int64, error)
+}
+
+// Clusterer groups the articles that cover the same story. An article joins
+// the cluster of the most similar recent article when their SimHash differs
+// in at most maxDistance bits, otherwise it starts a cluster of its own.
+// A cluster holds one article per source: the releases or tags of one
+// repository differ in a few bits only, yet every one of them is news.
+type Clusterer struct {
+	storage     FingerprintStorage
+	maxDistance int
+	window      time.Duration
+	now         func() time.Time
+
+	// mu serializes the assignment, otherwise two sources fetched at the same
+	// time could open two clusters for the same story.
+	mu sync.Mutex
+}
+
+func New(storage FingerprintStorage, maxDistance int, window time.Duration) *Clusterer {
+	return &Clusterer{
+		storage:     storage,
+		maxDistance: maxDistance,
+		window:      window,
+		now:         time.Now,
+	}
+}
+
+// Assign puts the stored article into a story cluster and returns the cluster id.
+func (c *Clusterer) Assign(ctx context.Context, article model.Article) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hash := SimHash(article.Title, article.Summary)
+
+	recent, err := c.storage.RecentFingerprints(ctx, c.now().Add(-c.window))
+	if err != nil {
+		return 0, err
+	}
+
+	// The clusters the source of the article is in already.
+	ownClusters := make(map[int64]struct{})
+	for _, fingerprint := range recent {
+		if fingerprint.SourceID == article.SourceID && fingerprint.ArticleID != article.ID {
+			ownClusters[fingerprint.ClusterID] = struct{}{}
+		}
+	}
+
+	var (
+		clusterID int64
+		best      = c.maxDistance + 1
+	)
+	for _, fingerprint := range recent {
+		if fingerprint.ArticleID == article.ID || fingerprint.ClusterID == 0 {
+			continue
+		}
+		if _, ok := ownClusters[fingerprint.ClusterID]; ok {
+			continue
+		}
+
+		if distance := Distance(hash, fingerprint.SimHash); distance < best {
+			best = distance
+			clusterID = fingerprint.ClusterID
+		}
+	}
+
+	return c.storage.AssignCluster(ctx, article.ID, hash, clusterID)
+}
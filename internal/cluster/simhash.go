@@ -0,0 +1,87 @@
+package cluster
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+
+	"github.com/ozaitsev92/gonewsbot/internal/htmlutil"
+)
+
+// titleWeight makes the title count more than the summary, which is often
+// a teaser of the same length for every story of a feed.
+const titleWeight = 3
+
+// stopWords carry no information about the story.
+var stopWords = map[string]struct{}{
+	"a": {}, "an": {}, "the": {}, "and": {}, "or": {}, "but": {}, "of": {}, "to": {}, "in": {},
+	"on": {}, "at": {}, "for": {}, "with": {}, "by": {}, "from": {}, "as": {}, "is": {}, "are": {},
+	"was": {}, "were": {}, "be": {}, "been": {}, "it": {}, "its": {}, "this": {}, "that": {},
+	"these": {}, "those": {}, "has": {}, "have": {}, "had": {}, "will": {}, "would": {}, "can": {},
+	"could": {}, "after": {}, "before": {}, "over": {}, "into": {}, "about": {}, "new": {},
+	"says": {}, "said": {}, "how": {}, "why": {}, "what": {}, "who": {}, "you": {}, "your": {},
+}
+
+// SimHash returns the 64 bit SimHash of the normalized title and summary.
+// Word unigrams and bigrams are the features, so that a reordered or
+// slightly reworded text keeps most of its bits.
+func SimHash(title string, summary string) uint64 {
+	var weights [64]int
+
+	add := func(tokens []string, weight int) {
+		for i, token := range tokens {
+			addFeature(&weights, token, weight)
+			if i > 0 {
+				addFeature(&weights, tokens[i-1]+" "+token, weight)
+			}
+		}
+	}
+	add(Tokens(title), titleWeight)
+	add(Tokens(htmlutil.PlainText(summary)), 1)
+
+	var hash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << bit
+		}
+	}
+
+	return hash
+}
+
+func addFeature(weights *[64]int, feature string, weight int) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	for bit := range weights {
+		if sum&(1<<bit) != 0 {
+			weights[bit] += weight
+		} else {
+			weights[bit] -= weight
+		}
+	}
+}
+
+// Distance is the number of bits two hashes differ in.
+func Distance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Tokens lower-cases the text, splits it into words and drops the stop words.
+func Tokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := words[:0]
+	for _, word := range words {
+		if _, ok := stopWords[word]; ok {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+
+	return tokens
+}
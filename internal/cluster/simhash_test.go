@@ -0,0 +1,166 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// testMaxDistance is the default of CLUSTER_MAX_DISTANCE.
+const testMaxDistance = 6
+
+type story struct {
+	Story   string `json:"story"`
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+}
+
+// loadStories reads the fixture of reworded headlines, the entries with the
+// same story field cover the same event.
+func loadStories(t *testing.T) []story {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "stories.json"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	var stories []story
+	if err := json.Unmarshal(data, &stories); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+
+	return stories
+}
+
+func TestSimHashDistance(t *testing.T) {
+	stories := loadStories(t)
+
+	for i := range stories {
+		for j := i + 1; j < len(stories); j++ {
+			a, b := stories[i], stories[j]
+			distance := Distance(SimHash(a.Title, a.Summary), SimHash(b.Title, b.Summary))
+
+			if a.Story == b.Story && distance > testMaxDistance {
+				t.Errorf("%q and %q differ in %d bits, want at most %d", a.Title, b.Title, distance, testMaxDistance)
+			}
+			if a.Story != b.Story && distance <= testMaxDistance {
+				t.Errorf("%q and %q differ in %d bits, want more than %d", a.Title, b.Title, distance, testMaxDistance)
+			}
+		}
+	}
+}
+
+func TestSimHashIgnoresMarkup(t *testing.T) {
+	plain := SimHash("Go 1.25 released", "The Go team released Go 1.25 today.")
+	markup := SimHash("Go 1.25 released", "<p>The <b>Go team</b> released Go 1.25 today.</p>")
+
+	if plain != markup {
+		t.Fatalf("SimHash() differs for the same text with markup: %x and %x", plain, markup)
+	}
+}
+
+type memoryFingerprints struct {
+	fingerprints []model.Fingerprint
+	clusters     int64
+	// sources are the source ids of the articles, AssignCluster does not get them.
+	sources map[int64]int64
+}
+
+func (s *memoryFingerprints) RecentFingerprints(_ context.Context, _ time.Time) ([]model.Fingerprint, error) {
+	return s.fingerprints, nil
+}
+
+func (s *memoryFingerprints) AssignCluster(_ context.Context, articleID int64, simHash uint64, clusterID int64) (int64, error) {
+	if clusterID == 0 {
+		s.clusters++
+		clusterID = s.clusters
+	}
+	s.fingerprints = append(s.fingerprints, model.Fingerprint{
+		ArticleID: articleID,
+		SourceID:  s.sources[articleID],
+		ClusterID: clusterID,
+		SimHash:   simHash,
+	})
+
+	return clusterID, nil
+}
+
+func TestClustererAssign(t *testing.T) {
+	stories := loadStories(t)
+
+	storage := &memoryFingerprints{sources: make(map[int64]int64)}
+	clusterer := New(storage, testMaxDistance, 48*time.Hour)
+	clusterer.now = func() time.Time { return time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC) }
+
+	clusterOf := make(map[string]int64)
+	storyOf := make(map[int64]string)
+	for i, s := range stories {
+		// Every story of the fixture comes from a source of its own.
+		id := int64(i + 1)
+		storage.sources[id] = id
+
+		clusterID, err := clusterer.Assign(context.Background(), model.Article{
+			ID:       id,
+			SourceID: id,
+			Title:    s.Title,
+			Summary:  s.Summary,
+		})
+		if err != nil {
+			t.Fatalf("Assign() error = %v", err)
+		}
+
+		if want, ok := clusterOf[s.Story]; ok && clusterID != want {
+			t.Errorf("%q is in cluster %d, want cluster %d of its story", s.Title, clusterID, want)
+		}
+		if other, ok := storyOf[clusterID]; ok && other != s.Story {
+			t.Errorf("%q joined cluster %d of the story %s", s.Title, clusterID, other)
+		}
+		clusterOf[s.Story] = clusterID
+		storyOf[clusterID] = s.Story
+	}
+
+	if int(storage.clusters) != len(clusterOf) {
+		t.Fatalf("Assign() opened %d clusters, want %d", storage.clusters, len(clusterOf))
+	}
+}
+
+func TestClustererKeepsReleasesOfOneSourceApart(t *testing.T) {
+	storage := &memoryFingerprints{sources: make(map[int64]int64)}
+	clusterer := New(storage, testMaxDistance, 48*time.Hour)
+
+	assign := func(id int64, sourceID int64, title string) int64 {
+		t.Helper()
+
+		storage.sources[id] = sourceID
+		clusterID, err := clusterer.Assign(context.Background(), model.Article{ID: id, SourceID: sourceID, Title: title})
+		if err != nil {
+			t.Fatalf("Assign() error = %v", err)
+		}
+
+		return clusterID
+	}
+
+	if d := Distance(SimHash("golang/go: go1.22.1", ""), SimHash("golang/go: go1.22.2", "")); d > testMaxDistance {
+		t.Fatalf("the releases differ in %d bits, the test needs them within the threshold", d)
+	}
+
+	// The releases feed of the repository and a news site that covers them.
+	first := assign(1, 1, "golang/go: go1.22.1")
+	second := assign(2, 1, "golang/go: go1.22.2")
+	if first == second {
+		t.Fatalf("two releases of one source share cluster %d", first)
+	}
+
+	if got := assign(3, 2, "golang/go: go1.22.1"); got != first {
+		t.Errorf("the other source's go1.22.1 is in cluster %d, want %d", got, first)
+	}
+	if got := assign(4, 2, "golang/go: go1.22.2"); got != second {
+		t.Errorf("the other source's go1.22.2 is in cluster %d, want %d", got, second)
+	}
+}
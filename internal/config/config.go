@@ -9,16 +9,41 @@ import (
 )
 
 type Config struct {
-	TelegramBotToken     string        `env:"TELEGRAM_BOT_TOKEN" required:"true"`
-	TelegramChannelID    int64         `env:"TELEGRAM_CHANNEL_ID" required:"true"`
-	DatabaseDSN          string        `env:"DATABASE_DSN" required:"true"`
-	FetchInterval        time.Duration `env:"FETCH_INTERVAL" default:"10m"`
-	NotificationInterval time.Duration `env:"NOTIFICATION_INTERVAL" default:"1m"`
-	FilterKeywords       []string      `env:"FILTER_KEYWORDS"`
-	OpenAIKey            string        `env:"OPENAI_KEY" required:"true"`
-	OpenAIPrompt         string        `env:"OPENAI_PROMPT" required:"true"`
-	OpenAIModel          string        `env:"OPENAI_MODEL" default:"gpt-3.5-turbo"`
-	HTTPBindAddress      string        `env:"HTTP_BIND_ADDRESS" default:":8080"`
+	TelegramBotToken      string        `env:"TELEGRAM_BOT_TOKEN" required:"true"`
+	TelegramChannelID     int64         `env:"TELEGRAM_CHANNEL_ID" required:"true"`
+	DatabaseDSN           string        `env:"DATABASE_DSN" required:"true"`
+	FetchInterval         time.Duration `env:"FETCH_INTERVAL" default:"10m"`
+	FetchSchedulerTick    time.Duration `env:"FETCH_SCHEDULER_TICK" default:"1m"`
+	FetchMaxBackoff       time.Duration `env:"FETCH_MAX_BACKOFF" default:"24h"`
+	FetchConcurrency      int           `env:"FETCH_CONCURRENCY" default:"10"`
+	FetchTimeout          time.Duration `env:"FETCH_TIMEOUT" default:"1m"`
+	FetchHostInterval     time.Duration `env:"FETCH_HOST_INTERVAL" default:"1s"`
+	FetchHostBurst        int           `env:"FETCH_HOST_BURST" default:"2"`
+	SourceMaxFailures     int           `env:"SOURCE_MAX_FAILURES" default:"10"`
+	SourceMaxIdle         time.Duration `env:"SOURCE_MAX_IDLE" default:"720h"`
+	NotificationInterval  time.Duration `env:"NOTIFICATION_INTERVAL" default:"1m"`
+	NotifierMaxAttempts   int           `env:"NOTIFIER_MAX_ATTEMPTS" default:"5"`
+	NotifierRetryDelay    time.Duration `env:"NOTIFIER_RETRY_DELAY" default:"5m"`
+	FilterKeywords        []string      `env:"FILTER_KEYWORDS"`
+	AllowedLanguages      []string      `env:"ALLOWED_LANGUAGES"`
+	ForeignLanguages      string        `env:"FOREIGN_LANGUAGES" default:"drop"`
+	OpenAIKey             string        `env:"OPENAI_KEY" required:"true"`
+	OpenAIPrompt          string        `env:"OPENAI_PROMPT" required:"true"`
+	OpenAIModel           string        `env:"OPENAI_MODEL" default:"gpt-3.5-turbo"`
+	GitHubToken           string        `env:"GITHUB_TOKEN"`
+	HTTPBindAddress       string        `env:"HTTP_BIND_ADDRESS" default:":8080"`
+	LeaderLockKey         int64         `env:"LEADER_LOCK_KEY" default:"7001"`
+	LeaderCheckInterval   time.Duration `env:"LEADER_CHECK_INTERVAL" default:"5s"`
+	APIToken              string        `env:"API_TOKEN"`
+	WebSubCallbackURL     string        `env:"WEBSUB_CALLBACK_URL"`
+	WebSubLease           time.Duration `env:"WEBSUB_LEASE" default:"168h"`
+	RankPriorityWeight    float64       `env:"RANK_PRIORITY_WEIGHT" default:"0.1"`
+	RankFreshnessHalfLife time.Duration `env:"RANK_FRESHNESS_HALF_LIFE" default:"6h"`
+	RankFairnessWeight    float64       `env:"RANK_FAIRNESS_WEIGHT" default:"0.2"`
+	RankFairnessWindow    time.Duration `env:"RANK_FAIRNESS_WINDOW" default:"24h"`
+	RankCandidates        uint64        `env:"RANK_CANDIDATES" default:"50"`
+	ClusterMaxDistance    int           `env:"CLUSTER_MAX_DISTANCE" default:"6"`
+	ClusterWindow         time.Duration `env:"CLUSTER_WINDOW" default:"48h"`
 }
 
 var cfg Config
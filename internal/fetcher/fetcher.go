@@ -2,21 +2,41 @@ package fetcher
 
 import (
 	"context"
+	"errors"
 	"log/slog"
-	"strings"
+	"net/http"
 	"sync"
 	"time"
 
+	"github.com/ozaitsev92/gonewsbot/internal/canonical"
+	"github.com/ozaitsev92/gonewsbot/internal/filter"
+	"github.com/ozaitsev92/gonewsbot/internal/language"
 	"github.com/ozaitsev92/gonewsbot/internal/model"
 	"github.com/ozaitsev92/gonewsbot/internal/source"
 )
 
 type ArticleStorage interface {
+	// AddArticle returns 0 when the article is already stored.
 	AddArticle(ctx context.Context, article model.Article) (int64, error)
 }
 
 type SourceProvider interface {
-	GetSources(ctx context.Context) ([]model.Source, error)
+	GetDueSources(ctx context.Context, now time.Time) ([]model.Source, error)
+	GetSourceByID(ctx context.Context, id int64) (*model.Source, error)
+	SetCacheValidators(ctx context.Context, id int64, etag string, lastModified string) error
+	SetCursor(ctx context.Context, id int64, cursor string) error
+	RecordFetchSuccess(ctx context.Context, id int64, nextFetchAt time.Time, lastItemAt time.Time) error
+	RecordFetchFailure(ctx context.Context, id int64, nextFetchAt time.Time, lastError string) error
+	DisableSource(ctx context.Context, id int64, reason string) error
+}
+
+type FetchRunStorage interface {
+	AddFetchRun(ctx context.Context, run model.FetchRun) error
+	AddFetchReport(ctx context.Context, report model.FetchReport) error
+}
+
+type Alerter interface {
+	AlertSourceDisabled(ctx context.Context, source model.Source, reason string) error
 }
 
 type Source interface {
@@ -25,29 +45,121 @@ type Source interface {
 	Fetch(ctx context.Context) ([]model.Item, error)
 }
 
+// CachedSource is implemented by sources that make conditional HTTP requests.
+type CachedSource interface {
+	Source
+	CacheValidators() source.Validators
+}
+
+// CursorSource is implemented by sources that resume from what the last fetch saw,
+// e.g. the position an API is paged from or the tags of a repository.
+type CursorSource interface {
+	Source
+	Cursor() string
+}
+
+// ProcessedSource is implemented by sources that consume what they read,
+// e.g. mailboxes. MarkProcessed is called once the items are stored.
+type ProcessedSource interface {
+	Source
+	MarkProcessed(ctx context.Context) error
+}
+
+// HubSource is implemented by sources whose feed may advertise a WebSub hub.
+type HubSource interface {
+	Source
+	WebSubLinks() source.WebSubLinks
+}
+
+type HubSubscriber interface {
+	EnsureSubscribed(ctx context.Context, sourceID int64, hubURL string, topicURL string) error
+}
+
+type FilterLoader interface {
+	Load(ctx context.Context) (*filter.Set, error)
+}
+
+type StoryClusterer interface {
+	Assign(ctx context.Context, article model.Article) (int64, error)
+}
+
 type Fetcher struct {
-	articles ArticleStorage
-	sources  SourceProvider
+	articles      ArticleStorage
+	sources       SourceProvider
+	runs          FetchRunStorage
+	alerter       Alerter
+	sourceFactory *SourceFactory
+	health        HealthPolicy
+	filters       FilterLoader
+	hubs          HubSubscriber
+	clusters      StoryClusterer
 
-	fetchInterval  time.Duration
-	filterKeywords []string
+	fetchInterval time.Duration
+	schedulerTick time.Duration
+	maxBackoff    time.Duration
+	// concurrency is how many sources are fetched at once, fetchTimeout how long one fetch may take.
+	concurrency  int
+	fetchTimeout time.Duration
+	// languages are allowed for the sources without a list of their own.
+	languages        []string
+	translateForeign bool
+	now              func() time.Time
 }
 
 func New(
 	articles ArticleStorage,
 	sources SourceProvider,
+	runs FetchRunStorage,
+	alerter Alerter,
+	sourceFactory *SourceFactory,
+	health HealthPolicy,
+	filters FilterLoader,
 	fetchInterval time.Duration,
-	filterKeywords []string,
+	schedulerTick time.Duration,
+	maxBackoff time.Duration,
+	concurrency int,
+	fetchTimeout time.Duration,
 ) *Fetcher {
 	return &Fetcher{
-		articles:       articles,
-		sources:        sources,
-		fetchInterval:  fetchInterval,
-		filterKeywords: filterKeywords,
+		articles:      articles,
+		sources:       sources,
+		runs:          runs,
+		alerter:       alerter,
+		sourceFactory: sourceFactory,
+		health:        health,
+		filters:       filters,
+		fetchInterval: fetchInterval,
+		schedulerTick: schedulerTick,
+		maxBackoff:    maxBackoff,
+		concurrency:   max(concurrency, 1),
+		fetchTimeout:  fetchTimeout,
+		now:           time.Now,
 	}
 }
+
+// SetHubSubscriber makes the fetcher subscribe the sources that advertise a
+// WebSub hub. Their pushed updates arrive next to the regular polling.
+func (f *Fetcher) SetHubSubscriber(hubs HubSubscriber) {
+	f.hubs = hubs
+}
+
+// SetLanguages sets the languages allowed for the sources without a list of
+// their own. Items in other languages are dropped, or with translate stored
+// to be summarized in the first allowed language.
+func (f *Fetcher) SetLanguages(allowed []string, translate bool) {
+	f.languages = allowed
+	f.translateForeign = translate
+}
+
+// SetClusterer makes the fetcher put every new article into a story cluster,
+// so that the notifier posts each story once.
+func (f *Fetcher) SetClusterer(clusters StoryClusterer) {
+	f.clusters = clusters
+}
+
+// Start checks for due sources every scheduler tick until the context is cancelled.
 func (f *Fetcher) Start(ctx context.Context) error {
-	ticker := time.NewTicker(f.fetchInterval)
+	ticker := time.NewTicker(f.schedulerTick)
 	defer ticker.Stop()
 
 	for {
@@ -62,74 +174,293 @@ func (f *Fetcher) Start(ctx context.Context) error {
 	}
 }
 
+// Fetch fetches all sources that are due according to their schedule,
+// at most concurrency of them at once, and stores a report of the run.
 func (f *Fetcher) Fetch(ctx context.Context) error {
-	sources, err := f.sources.GetSources(ctx)
+	report := newRunReport(model.FetchReport{StartedAt: f.now(), Errors: make(map[string]int)})
+
+	sources, err := f.sources.GetDueSources(ctx, f.now())
 	if err != nil {
 		return err
 	}
 
+	type job struct {
+		src        model.Source
+		feedSource Source
+	}
+
+	jobs := make(chan job)
 	var wg sync.WaitGroup
 
-	for _, src := range sources {
+	for range min(f.concurrency, len(sources)) {
 		wg.Add(1)
 
-		rssSource := source.NewRSSSourceFromModel(src)
-
-		go func(source Source) {
+		go func() {
 			defer wg.Done()
 
-			items, err := source.Fetch(ctx)
-			if err != nil {
-				slog.Error("failed to fetch items", "source", source.Name(), "error", err)
-				return
+			for j := range jobs {
+				f.fetchSource(ctx, j.src, j.feedSource, report)
 			}
+		}()
+	}
 
-			if err := f.processItems(ctx, source, items); err != nil {
-				slog.Error("failed to process items", "source", source.Name(), "error", err)
-				return
-			}
-		}(rssSource)
+	for _, src := range sources {
+		feedSource, err := f.sourceFactory.New(src)
+		if err != nil {
+			slog.Error("failed to create source", "source", src.Name, "error", err)
+			f.recordFailure(ctx, src, err)
+			report.add(model.FetchRun{Status: model.FetchStatusError, ErrorClass: model.FetchErrorConfig}, itemStats{})
+			continue
+		}
+
+		// The sources left when the context is cancelled stay due for the next run.
+		select {
+		case jobs <- job{src: src, feedSource: feedSource}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
 	}
 
+	close(jobs)
 	wg.Wait()
 
+	f.saveReport(ctx, report)
+
 	return nil
 }
 
-func (f *Fetcher) processItems(ctx context.Context, source Source, items []model.Item) error {
+func (f *Fetcher) fetchSource(ctx context.Context, src model.Source, feedSource Source, report *runReport) {
+	run := model.FetchRun{
+		SourceID:  src.ID,
+		StartedAt: f.now(),
+	}
+	var stats itemStats
+	defer func() {
+		run.Duration = f.now().Sub(run.StartedAt)
+		if err := f.runs.AddFetchRun(ctx, run); err != nil {
+			slog.Error("failed to record fetch run", "source", feedSource.Name(), "error", err)
+		}
+		report.add(run, stats)
+	}()
+
+	items, err := f.fetchItems(ctx, feedSource)
+	if err != nil {
+		if errors.Is(err, source.ErrNotModified) {
+			slog.Debug("source not modified", "source", feedSource.Name())
+			run.Status = model.FetchStatusNotModified
+			run.HTTPStatus = http.StatusNotModified
+			f.recordSuccess(ctx, src, 0)
+			return
+		}
+
+		run.Status = model.FetchStatusError
+		run.Error = err.Error()
+		run.ErrorClass = classifyFetchError(err)
+		slog.Error("failed to fetch items", "source", feedSource.Name(), "error_class", run.ErrorClass, "error", err)
+		var statusErr *source.StatusError
+		if errors.As(err, &statusErr) {
+			run.HTTPStatus = statusErr.Code
+		}
+		f.recordFailure(ctx, src, err)
+		return
+	}
+
+	run.ItemCount = len(items)
+
+	stats, err = f.processItems(ctx, src, items)
+	run.NewItemCount = stats.Added
+	if err != nil {
+		slog.Error("failed to process items", "source", feedSource.Name(), "error", err)
+		run.Status = model.FetchStatusError
+		run.Error = err.Error()
+		run.ErrorClass = model.FetchErrorDB
+		f.recordFailure(ctx, src, err)
+		return
+	}
+
+	run.Status = model.FetchStatusOK
+	run.HTTPStatus = http.StatusOK
+	f.recordSuccess(ctx, src, stats.Added)
+
+	// The validators and the cursor are saved only after the items are stored, otherwise
+	// a failed insert would be hidden behind 304 responses or skipped by the next page.
+	if cached, ok := feedSource.(CachedSource); ok {
+		validators := cached.CacheValidators()
+		if validators.ETag != src.ETag || validators.LastModified != src.LastModified {
+			if err := f.sources.SetCacheValidators(ctx, src.ID, validators.ETag, validators.LastModified); err != nil {
+				slog.Error("failed to save cache validators", "source", feedSource.Name(), "error", err)
+			}
+		}
+	}
+
+	if paged, ok := feedSource.(CursorSource); ok {
+		if cursor := paged.Cursor(); cursor != src.Cursor {
+			if err := f.sources.SetCursor(ctx, src.ID, cursor); err != nil {
+				slog.Error("failed to save cursor", "source", feedSource.Name(), "error", err)
+			}
+		}
+	}
+
+	if consumer, ok := feedSource.(ProcessedSource); ok {
+		if err := consumer.MarkProcessed(ctx); err != nil {
+			slog.Error("failed to mark items as processed", "source", feedSource.Name(), "error", err)
+		}
+	}
+
+	f.subscribeToHub(ctx, src, feedSource)
+}
+
+// fetchItems fetches the source within the fetch timeout, so that a slow
+// server does not hold a worker of the pool forever.
+func (f *Fetcher) fetchItems(ctx context.Context, feedSource Source) ([]model.Item, error) {
+	if f.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.fetchTimeout)
+		defer cancel()
+	}
+
+	return feedSource.Fetch(ctx)
+}
+
+func (f *Fetcher) subscribeToHub(ctx context.Context, src model.Source, feedSource Source) {
+	hubSource, ok := feedSource.(HubSource)
+	if !ok || f.hubs == nil {
+		return
+	}
+
+	links := hubSource.WebSubLinks()
+	if links.Hub == "" {
+		return
+	}
+
+	topic := links.Self
+	if topic == "" {
+		topic = src.FeedURL
+	}
+
+	if err := f.hubs.EnsureSubscribed(ctx, src.ID, links.Hub, topic); err != nil {
+		slog.Error("failed to subscribe to websub hub", "source", src.Name, "hub", links.Hub, "error", err)
+	}
+}
+
+func (f *Fetcher) recordSuccess(ctx context.Context, src model.Source, added int) {
+	var lastItemAt time.Time
+	if added > 0 {
+		lastItemAt = f.now()
+	}
+
+	if err := f.sources.RecordFetchSuccess(ctx, src.ID, f.nextFetchAfterSuccess(src), lastItemAt); err != nil {
+		slog.Error("failed to record fetch success", "source", src.Name, "error", err)
+	}
+
+	if added == 0 {
+		f.disableIfIdle(ctx, src)
+	}
+}
+
+func (f *Fetcher) recordFailure(ctx context.Context, src model.Source, fetchErr error) {
+	if err := f.sources.RecordFetchFailure(ctx, src.ID, f.nextFetchAfterFailure(src), fetchErr.Error()); err != nil {
+		slog.Error("failed to record fetch failure", "source", src.Name, "error", err)
+	}
+
+	f.disableIfFailing(ctx, src, fetchErr)
+}
+
+// processItems stores the items that pass the filters and tells how many of them were new.
+func (f *Fetcher) processItems(ctx context.Context, src model.Source, items []model.Item) (itemStats, error) {
+	var stats itemStats
+
+	// Nothing is stored unfiltered, a failed load fails the fetch.
+	filters, err := f.filters.Load(ctx)
+	if err != nil {
+		return stats, err
+	}
+
 	for _, item := range items {
-		if f.itemShouldBeSkipped(item) {
+		translateTo, ok := f.admit(filters, src, &item)
+		if !ok {
+			stats.Filtered++
 			continue
 		}
 
+		// Items without a date, e.g. scraped ones, count as published when first seen.
+		publishedAt := item.Date
+		if publishedAt.IsZero() {
+			publishedAt = f.now()
+		}
+
 		article := model.Article{
-			SourceID:    source.ID(),
-			Title:       item.Title,
-			Link:        item.Link,
-			Summary:     item.Summary,
-			PublishedAt: item.Date,
+			SourceID:      src.ID,
+			Title:         truncateTitle(item.Title),
+			Link:          item.Link,
+			CanonicalLink: canonicalLink(item),
+			Summary:       item.Summary,
+			Duration:      item.Duration,
+			Thumbnail:     item.Thumbnail,
+			Language:      item.Language,
+			TranslateTo:   translateTo,
+			PublishedAt:   publishedAt,
+		}
+		if len(item.Enclosures) > 0 {
+			article.EnclosureURL = item.Enclosures[0].URL
+		}
+
+		id, err := f.articles.AddArticle(ctx, article)
+		if err != nil {
+			return stats, err
+		}
+		if id == 0 {
+			stats.Duplicates++
+			continue
 		}
+		stats.Added++
 
-		if _, err := f.articles.AddArticle(ctx, article); err != nil {
-			return err
+		// An article without a cluster is still posted, just not grouped with its duplicates.
+		if f.clusters != nil {
+			article.ID = id
+			if _, err := f.clusters.Assign(ctx, article); err != nil {
+				slog.Error("failed to assign story cluster", "article_id", id, "error", err)
+			}
 		}
 	}
 
-	return nil
+	return stats, nil
 }
 
-func (f *Fetcher) itemShouldBeSkipped(item model.Item) bool {
-	categories := make(map[string]struct{}, len(item.Categories))
-	for _, category := range item.Categories {
-		categories[category] = struct{}{}
+// admit detects the language of the item and checks the item against the
+// rules and the allowed languages of the source. An item in a language that
+// is not allowed is dropped, or kept with the language to translate it into.
+func (f *Fetcher) admit(filters *filter.Set, src model.Source, item *model.Item) (string, bool) {
+	item.Language = language.Detect(item.Title, item.Summary)
+
+	if !filters.Allows(src.ID, *item) {
+		return "", false
 	}
 
-	title := strings.ToLower(item.Title)
-	for _, keyword := range f.filterKeywords {
-		if _, exists := categories[keyword]; exists || strings.Contains(title, keyword) {
-			return true
-		}
+	allowed := src.Languages
+	if len(allowed) == 0 {
+		allowed = f.languages
+	}
+
+	if language.Allowed(item.Language, allowed) {
+		return "", true
+	}
+	if !f.translateForeign {
+		return "", false
+	}
+
+	return allowed[0], true
+}
+
+// canonicalLink normalizes the link of the item. A <link rel="canonical"> is
+// honored when the feed ships the full page as the content; the page is not
+// downloaded just to look for it.
+func canonicalLink(item model.Item) string {
+	if link := canonical.LinkFromHTML(item.Content, item.Link); link != "" {
+		return canonical.URL(link)
 	}
 
-	return false
+	return canonical.URL(item.Link)
 }
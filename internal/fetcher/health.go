@@ -0,0 +1,61 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// HealthPolicy decides when a source is considered dead and gets disabled.
+// Zero values turn the corresponding check off.
+type HealthPolicy struct {
+	MaxConsecutiveFailures int
+	MaxIdle                time.Duration
+}
+
+func (f *Fetcher) disableIfFailing(ctx context.Context, src model.Source, fetchErr error) {
+	failures := src.ConsecutiveFailures + 1
+	if f.health.MaxConsecutiveFailures <= 0 || failures < f.health.MaxConsecutiveFailures {
+		return
+	}
+
+	f.disable(ctx, src, fmt.Sprintf("%d failed fetches in a row, last error: %s", failures, fetchErr))
+}
+
+func (f *Fetcher) disableIfIdle(ctx context.Context, src model.Source) {
+	if f.health.MaxIdle <= 0 {
+		return
+	}
+
+	lastItemAt := src.LastItemAt
+	if lastItemAt.IsZero() {
+		lastItemAt = src.CreatedAt
+	}
+	// The sources added before the creation time was stored have neither.
+	if lastItemAt.IsZero() {
+		return
+	}
+
+	idle := f.now().Sub(lastItemAt)
+	if idle < f.health.MaxIdle {
+		return
+	}
+
+	f.disable(ctx, src, fmt.Sprintf("no new items for %d days", int(idle.Hours()/24)))
+}
+
+func (f *Fetcher) disable(ctx context.Context, src model.Source, reason string) {
+	slog.Warn("disabling source", "source", src.Name, "reason", reason)
+
+	if err := f.sources.DisableSource(ctx, src.ID, reason); err != nil {
+		slog.Error("failed to disable source", "source", src.Name, "error", err)
+		return
+	}
+
+	if err := f.alerter.AlertSourceDisabled(ctx, src, reason); err != nil {
+		slog.Error("failed to alert admins", "source", src.Name, "error", err)
+	}
+}
@@ -0,0 +1,20 @@
+package fetcher
+
+import (
+	"context"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// Ingest stores items submitted by external systems for the source. They go
+// through the same filters and deduplication as fetched items.
+// It returns how many of the items were new.
+func (f *Fetcher) Ingest(ctx context.Context, sourceID int64, items []model.Item) (int, error) {
+	src, err := f.sources.GetSourceByID(ctx, sourceID)
+	if err != nil {
+		return 0, err
+	}
+
+	stats, err := f.processItems(ctx, *src, items)
+	return stats.Added, err
+}
@@ -0,0 +1,74 @@
+package fetcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// TitledSource is implemented by sources that know the title of their feed after a fetch.
+type TitledSource interface {
+	Source
+	Title() string
+}
+
+// Preview describes what a source would bring in without storing anything.
+type Preview struct {
+	Title     string
+	ItemCount int
+	Newest    time.Time
+	Filtered  int
+}
+
+// Preview does a dry-run fetch of the source and applies the current filters to its items.
+func (f *Fetcher) Preview(ctx context.Context, src model.Source) (Preview, error) {
+	feedSource, items, err := f.dryRun(ctx, src)
+	if err != nil {
+		return Preview{}, err
+	}
+
+	filters, err := f.filters.Load(ctx)
+	if err != nil {
+		return Preview{}, err
+	}
+
+	preview := Preview{
+		ItemCount: len(items),
+	}
+
+	if titled, ok := feedSource.(TitledSource); ok {
+		preview.Title = titled.Title()
+	}
+
+	for _, item := range items {
+		if item.Date.After(preview.Newest) {
+			preview.Newest = item.Date
+		}
+		if _, ok := f.admit(filters, src, &item); !ok {
+			preview.Filtered++
+		}
+	}
+
+	return preview, nil
+}
+
+// PreviewItems does a dry-run fetch of the source and returns the items as they were fetched.
+func (f *Fetcher) PreviewItems(ctx context.Context, src model.Source) ([]model.Item, error) {
+	_, items, err := f.dryRun(ctx, src)
+	return items, err
+}
+
+func (f *Fetcher) dryRun(ctx context.Context, src model.Source) (Source, []model.Item, error) {
+	feedSource, err := f.sourceFactory.New(src)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items, err := f.fetchItems(ctx, feedSource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return feedSource, items, nil
+}
@@ -0,0 +1,101 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/url"
+	"sync"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+	"github.com/ozaitsev92/gonewsbot/internal/source"
+)
+
+// itemStats tells what happened to the fetched items of a source.
+type itemStats struct {
+	Added      int
+	Duplicates int
+	Filtered   int
+}
+
+// runReport collects the outcome of the sources fetched by one run.
+type runReport struct {
+	mu     sync.Mutex
+	report model.FetchReport
+}
+
+func newRunReport(report model.FetchReport) *runReport {
+	return &runReport{report: report}
+}
+
+func (r *runReport) add(run model.FetchRun, stats itemStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.SourcesAttempted++
+	if run.Status == model.FetchStatusError {
+		r.report.SourcesFailed++
+		r.report.Errors[run.ErrorClass]++
+	} else {
+		r.report.SourcesSucceeded++
+	}
+
+	r.report.ItemsSeen += run.ItemCount
+	r.report.ItemsInserted += stats.Added
+	r.report.ItemsDuplicate += stats.Duplicates
+	r.report.ItemsFiltered += stats.Filtered
+}
+
+// save logs and stores the report. Runs over no due sources are not stored,
+// the scheduler would fill the table with empty reports otherwise.
+func (f *Fetcher) saveReport(ctx context.Context, r *runReport) {
+	report := r.report
+	if report.SourcesAttempted == 0 {
+		return
+	}
+	report.Duration = f.now().Sub(report.StartedAt)
+
+	slog.Info(
+		"fetch run finished",
+		"duration", report.Duration,
+		"sources_attempted", report.SourcesAttempted,
+		"sources_succeeded", report.SourcesSucceeded,
+		"sources_failed", report.SourcesFailed,
+		"items_seen", report.ItemsSeen,
+		"items_inserted", report.ItemsInserted,
+		"items_duplicate", report.ItemsDuplicate,
+		"items_filtered", report.ItemsFiltered,
+		"errors", report.Errors,
+	)
+
+	// A run cut short by the shutdown is still worth a report.
+	if err := f.runs.AddFetchReport(context.WithoutCancel(ctx), report); err != nil {
+		slog.Error("failed to record fetch report", "error", err)
+	}
+}
+
+// classifyFetchError tells why a source could not be fetched. The errors
+// that are neither network nor HTTP errors come from reading the response,
+// which for the HTTP sources is the parsing.
+func classifyFetchError(err error) string {
+	var (
+		dnsErr    *net.DNSError
+		statusErr *source.StatusError
+		netErr    net.Error
+		urlErr    *url.Error
+	)
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return model.FetchErrorDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return model.FetchErrorTimeout
+	case errors.As(err, &statusErr):
+		return model.FetchErrorHTTPStatus
+	case errors.As(err, &netErr), errors.As(err, &urlErr):
+		return model.FetchErrorNetwork
+	default:
+		return model.FetchErrorParse
+	}
+}
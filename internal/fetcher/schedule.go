@@ -0,0 +1,42 @@
+package fetcher
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// maxBackoffExponent keeps the shift in backoff from overflowing for long failure streaks.
+const maxBackoffExponent = 16
+
+func (f *Fetcher) intervalFor(src model.Source) time.Duration {
+	if src.FetchInterval > 0 {
+		return src.FetchInterval
+	}
+	return f.fetchInterval
+}
+
+// nextFetchAfterSuccess schedules the next regular fetch of the source.
+func (f *Fetcher) nextFetchAfterSuccess(src model.Source) time.Time {
+	return f.now().Add(f.intervalFor(src))
+}
+
+// nextFetchAfterFailure doubles the source interval for every consecutive
+// failure, caps it at maxBackoff and picks a random point in the upper half
+// so that sources that broke together do not retry in lockstep.
+func (f *Fetcher) nextFetchAfterFailure(src model.Source) time.Time {
+	exponent := min(src.ConsecutiveFailures+1, maxBackoffExponent)
+
+	delay := f.intervalFor(src) << exponent
+	if delay <= 0 || delay > f.maxBackoff {
+		delay = f.maxBackoff
+	}
+
+	half := delay / 2
+	if half > 0 {
+		delay = half + time.Duration(rand.Int64N(int64(half)))
+	}
+
+	return f.now().Add(delay)
+}
@@ -0,0 +1,58 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+	"github.com/ozaitsev92/gonewsbot/internal/source"
+)
+
+// SourceFactory builds the Source implementation matching the source type.
+type SourceFactory struct {
+	client      *http.Client
+	githubToken string
+	// mastodonAccounts is shared by the Mastodon sources of all the fetches.
+	mastodonAccounts *source.MastodonAccounts
+}
+
+func NewSourceFactory(client *http.Client, githubToken string) *SourceFactory {
+	return &SourceFactory{
+		client:           client,
+		githubToken:      githubToken,
+		mastodonAccounts: source.NewMastodonAccounts(),
+	}
+}
+
+func (f *SourceFactory) New(m model.Source) (Source, error) {
+	switch m.Type {
+	case model.SourceTypeRSS, "":
+		return source.NewRSSSourceFromModel(m, f.client), nil
+	case model.SourceTypeAtom:
+		return source.NewAtomSourceFromModel(m, f.client), nil
+	case model.SourceTypeJSONFeed:
+		return source.NewJSONFeedSourceFromModel(m, f.client), nil
+	case model.SourceTypeHTML:
+		return source.NewHTMLSourceFromModel(m, f.client)
+	case model.SourceTypeHackerNews:
+		return source.NewHackerNewsSourceFromModel(m, f.client)
+	case model.SourceTypeReddit:
+		return source.NewRedditSourceFromModel(m, f.client)
+	case model.SourceTypePodcast:
+		return source.NewPodcastSourceFromModel(m, f.client), nil
+	case model.SourceTypeYouTube:
+		return source.NewYouTubeSourceFromModel(m, f.client)
+	case model.SourceTypeMastodon:
+		src, err := source.NewMastodonSourceFromModel(m, f.client)
+		if err != nil {
+			return nil, err
+		}
+		return src.WithAccounts(f.mastodonAccounts), nil
+	case model.SourceTypeIMAP:
+		return source.NewIMAPSourceFromModel(m)
+	case model.SourceTypeGitHub:
+		return source.NewGitHubReleasesSourceFromModel(m, f.client, f.githubToken)
+	default:
+		return nil, fmt.Errorf("unknown source type %q", m.Type)
+	}
+}
@@ -0,0 +1,17 @@
+package fetcher
+
+import "unicode/utf8"
+
+// maxTitleLength is the size of the title column of the articles table.
+const maxTitleLength = 255
+
+// truncateTitle shortens the titles that would not fit the articles table.
+// Any source can have them, e.g. Reddit allows titles of 300 characters and
+// scraped pages or e-mail subjects have no limit at all.
+func truncateTitle(title string) string {
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return string([]rune(title)[:maxTitleLength-1]) + "…"
+	}
+
+	return title
+}
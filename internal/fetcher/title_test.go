@@ -0,0 +1,73 @@
+package fetcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ozaitsev92/gonewsbot/internal/filter"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+func TestTruncateTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  int
+	}{
+		{name: "short", title: "Hello", want: 5},
+		{name: "at the limit", title: strings.Repeat("a", maxTitleLength), want: maxTitleLength},
+		{name: "too long", title: strings.Repeat("a", 1000), want: maxTitleLength},
+		{name: "too long multibyte", title: strings.Repeat("я", 300), want: maxTitleLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateTitle(tt.title)
+			if n := utf8.RuneCountInString(got); n != tt.want {
+				t.Errorf("truncateTitle() has %d runes, want %d", n, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateTitle() = %q is not valid UTF-8", got)
+			}
+		})
+	}
+}
+
+type memoryArticles struct {
+	articles []model.Article
+}
+
+func (s *memoryArticles) AddArticle(_ context.Context, article model.Article) (int64, error) {
+	s.articles = append(s.articles, article)
+	return int64(len(s.articles)), nil
+}
+
+type noFilters struct{}
+
+func (noFilters) Load(context.Context) (*filter.Set, error) {
+	return filter.NewSet(nil), nil
+}
+
+func TestProcessItemsTruncatesTitles(t *testing.T) {
+	articles := &memoryArticles{}
+	f := &Fetcher{articles: articles, filters: noFilters{}, now: time.Now}
+
+	// Reddit allows titles of 300 characters, more than the title column holds.
+	title := strings.Repeat("Long Reddit title ", 17)[:300]
+	stats, err := f.processItems(context.Background(), model.Source{ID: 1}, []model.Item{
+		{Title: title, Link: "https://www.reddit.com/r/golang/comments/1/long/"},
+	})
+	if err != nil {
+		t.Fatalf("processItems() error = %v", err)
+	}
+
+	if stats.Added != 1 || len(articles.articles) != 1 {
+		t.Fatalf("processItems() added %d articles, want 1", stats.Added)
+	}
+	if n := utf8.RuneCountInString(articles.articles[0].Title); n != maxTitleLength {
+		t.Fatalf("stored title has %d runes, want %d", n, maxTitleLength)
+	}
+}
@@ -0,0 +1,219 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const (
+	FieldTitle    = "title"
+	FieldSummary  = "summary"
+	FieldCategory = "category"
+	FieldAuthor   = "author"
+	FieldDomain   = "domain"
+	FieldLanguage = "language"
+)
+
+const (
+	MatchContains = "contains"
+	MatchWord     = "word"
+	MatchRegex    = "regex"
+	MatchEquals   = "equals"
+)
+
+// Condition is a node of the condition tree of a rule. A node either combines
+// other nodes with "all", "any" or "not", or matches one field of the item:
+//
+//	{"all": [
+//		{"field": "title", "match": "word", "value": "go"},
+//		{"not": {"field": "domain", "match": "equals", "value": "example.com"}}
+//	]}
+//
+// Text matches ignore case, regular expressions can opt in with (?i) themselves.
+// A category matches when any of the categories of the item does. The domain
+// is the host of the link without "www.", "equals" accepts its subdomains too.
+// The language is the detected ISO 639-1 code, empty when it is unknown.
+type Condition struct {
+	All   []Condition `json:"all,omitempty"`
+	Any   []Condition `json:"any,omitempty"`
+	Not   *Condition  `json:"not,omitempty"`
+	Field string      `json:"field,omitempty"`
+	Match string      `json:"match,omitempty"`
+	Value string      `json:"value,omitempty"`
+}
+
+// Predicate reports whether the item satisfies a compiled condition.
+type Predicate func(item model.Item) bool
+
+// Parse decodes and compiles a JSON condition. Unknown keys are rejected,
+// so that a typo does not silently turn into a condition that always matches.
+func Parse(raw json.RawMessage) (Predicate, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+
+	var condition Condition
+	if err := decoder.Decode(&condition); err != nil {
+		return nil, fmt.Errorf("invalid condition: %w", err)
+	}
+
+	return condition.Compile()
+}
+
+// Compile checks the condition and turns it into a predicate.
+func (c Condition) Compile() (Predicate, error) {
+	set := 0
+	for _, ok := range []bool{c.All != nil, c.Any != nil, c.Not != nil, c.Field != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New(`a condition needs exactly one of "all", "any", "not" or "field"`)
+	}
+
+	switch {
+	case c.All != nil:
+		predicates, err := compileAll(c.All)
+		if err != nil {
+			return nil, err
+		}
+		return func(item model.Item) bool {
+			for _, predicate := range predicates {
+				if !predicate(item) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case c.Any != nil:
+		predicates, err := compileAll(c.Any)
+		if err != nil {
+			return nil, err
+		}
+		return func(item model.Item) bool {
+			for _, predicate := range predicates {
+				if predicate(item) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case c.Not != nil:
+		predicate, err := c.Not.Compile()
+		if err != nil {
+			return nil, err
+		}
+		return func(item model.Item) bool {
+			return !predicate(item)
+		}, nil
+	default:
+		return c.compileField()
+	}
+}
+
+func compileAll(conditions []Condition) ([]Predicate, error) {
+	if len(conditions) == 0 {
+		return nil, errors.New(`"all" and "any" need at least one condition`)
+	}
+
+	predicates := make([]Predicate, len(conditions))
+	for i, condition := range conditions {
+		predicate, err := condition.Compile()
+		if err != nil {
+			return nil, err
+		}
+		predicates[i] = predicate
+	}
+
+	return predicates, nil
+}
+
+func (c Condition) compileField() (Predicate, error) {
+	var values func(item model.Item) []string
+	switch c.Field {
+	case FieldTitle:
+		values = func(item model.Item) []string { return []string{item.Title} }
+	case FieldSummary:
+		values = func(item model.Item) []string { return []string{item.Summary} }
+	case FieldCategory:
+		values = func(item model.Item) []string { return item.Categories }
+	case FieldAuthor:
+		values = func(item model.Item) []string { return []string{item.Author} }
+	case FieldDomain:
+		values = func(item model.Item) []string { return []string{domain(item.Link)} }
+	case FieldLanguage:
+		values = func(item model.Item) []string { return []string{item.Language} }
+	default:
+		return nil, fmt.Errorf("unknown field %q", c.Field)
+	}
+
+	if c.Value == "" {
+		return nil, fmt.Errorf("field %q needs a value", c.Field)
+	}
+
+	matches, err := c.compileMatch()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(item model.Item) bool {
+		for _, value := range values(item) {
+			if matches(value) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func (c Condition) compileMatch() (func(value string) bool, error) {
+	want := strings.ToLower(c.Value)
+
+	switch c.Match {
+	case MatchContains, "":
+		return func(value string) bool {
+			return strings.Contains(strings.ToLower(value), want)
+		}, nil
+	case MatchEquals:
+		if c.Field == FieldDomain {
+			want = strings.TrimPrefix(want, "www.")
+			return func(value string) bool {
+				return value == want || strings.HasSuffix(value, "."+want)
+			}, nil
+		}
+		return func(value string) bool {
+			return strings.EqualFold(strings.TrimSpace(value), want)
+		}, nil
+	case MatchWord:
+		// \b of the regexp package only knows ASCII letters.
+		re, err := regexp.Compile(`(?i)(?:^|[^\p{L}\p{N}_])` + regexp.QuoteMeta(c.Value) + `(?:$|[^\p{L}\p{N}_])`)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	case MatchRegex:
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", c.Value, err)
+		}
+		return re.MatchString, nil
+	default:
+		return nil, fmt.Errorf("unknown match %q", c.Match)
+	}
+}
+
+func domain(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
@@ -0,0 +1,108 @@
+package filter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+func TestParse(t *testing.T) {
+	item := model.Item{
+		Title:      "Go 1.25 is released",
+		Summary:    "The Go team released Go 1.25 with a new garbage collector.",
+		Link:       "https://www.blog.example.com/go-1-25",
+		Author:     "Alice",
+		Categories: []string{"Golang", "Releases"},
+		Language:   "en",
+	}
+
+	tests := []struct {
+		name      string
+		condition string
+		want      bool
+	}{
+		{name: "contains ignores case", condition: `{"field": "title", "value": "RELEASED"}`, want: true},
+		{name: "contains", condition: `{"field": "summary", "match": "contains", "value": "garbage"}`, want: true},
+		{name: "word", condition: `{"field": "title", "match": "word", "value": "go"}`, want: true},
+		{name: "word inside another word", condition: `{"field": "summary", "match": "word", "value": "collect"}`, want: false},
+		{name: "word with punctuation", condition: `{"field": "title", "match": "word", "value": "1.25"}`, want: true},
+		{name: "word of another alphabet", condition: `{"field": "title", "match": "word", "value": "го"}`, want: false},
+		{name: "regex", condition: `{"field": "title", "match": "regex", "value": "Go 1\\.\\d+"}`, want: true},
+		{name: "regex is case sensitive", condition: `{"field": "title", "match": "regex", "value": "^go"}`, want: false},
+		{name: "regex opts in to ignore case", condition: `{"field": "title", "match": "regex", "value": "(?i)^go"}`, want: true},
+		{name: "equals", condition: `{"field": "author", "match": "equals", "value": "alice"}`, want: true},
+		{name: "equals needs the whole value", condition: `{"field": "author", "match": "equals", "value": "ali"}`, want: false},
+		{name: "any category", condition: `{"field": "category", "match": "equals", "value": "releases"}`, want: true},
+		{name: "domain", condition: `{"field": "domain", "match": "equals", "value": "blog.example.com"}`, want: true},
+		{name: "subdomain", condition: `{"field": "domain", "match": "equals", "value": "www.example.com"}`, want: true},
+		{name: "other domain", condition: `{"field": "domain", "match": "equals", "value": "ample.com"}`, want: false},
+		{name: "language", condition: `{"field": "language", "match": "equals", "value": "en"}`, want: true},
+		{
+			name:      "all",
+			condition: `{"all": [{"field": "title", "value": "go"}, {"field": "author", "value": "alice"}]}`,
+			want:      true,
+		},
+		{
+			name:      "all with one miss",
+			condition: `{"all": [{"field": "title", "value": "go"}, {"field": "author", "value": "bob"}]}`,
+			want:      false,
+		},
+		{
+			name:      "any",
+			condition: `{"any": [{"field": "title", "value": "rust"}, {"field": "author", "value": "alice"}]}`,
+			want:      true,
+		},
+		{
+			name:      "any without a match",
+			condition: `{"any": [{"field": "title", "value": "rust"}, {"field": "author", "value": "bob"}]}`,
+			want:      false,
+		},
+		{name: "not", condition: `{"not": {"field": "domain", "match": "equals", "value": "example.com"}}`, want: false},
+		{
+			name:      "nested",
+			condition: `{"all": [{"field": "title", "match": "word", "value": "go"}, {"not": {"any": [{"field": "category", "value": "rust"}, {"field": "language", "match": "equals", "value": "de"}]}}]}`,
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := Parse(json.RawMessage(tt.condition))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got := matches(item); got != tt.want {
+				t.Errorf("condition %s matched = %v, want %v", tt.condition, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+	}{
+		{name: "not json", condition: `title contains go`},
+		{name: "unknown key", condition: `{"field": "title", "value": "go", "case": "sensitive"}`},
+		{name: "empty", condition: `{}`},
+		{name: "field and all", condition: `{"field": "title", "value": "go", "all": [{"field": "author", "value": "alice"}]}`},
+		{name: "empty all", condition: `{"all": []}`},
+		{name: "empty any", condition: `{"any": []}`},
+		{name: "unknown field", condition: `{"field": "body", "value": "go"}`},
+		{name: "missing value", condition: `{"field": "title", "match": "word"}`},
+		{name: "unknown match", condition: `{"field": "title", "match": "fuzzy", "value": "go"}`},
+		{name: "invalid regex", condition: `{"field": "title", "match": "regex", "value": "go("}`},
+		{name: "invalid nested condition", condition: `{"not": {"any": [{"field": "title"}]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(json.RawMessage(tt.condition)); err == nil {
+				t.Errorf("Parse(%s) error = nil, want an error", tt.condition)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+type RuleStorage interface {
+	GetFilterRules(ctx context.Context) ([]model.FilterRule, error)
+}
+
+type rule struct {
+	model.FilterRule
+	matches Predicate
+}
+
+// Set is a compiled set of rules. An item is dropped when it matches an
+// exclude rule of its source or a global one. When include rules apply to
+// the source, the item is kept only if it matches at least one of them.
+type Set struct {
+	rules []rule
+}
+
+// NewSet compiles the rules. A rule that does not compile is left out and
+// logged, so that one broken rule does not stop the fetching.
+func NewSet(rules []model.FilterRule) *Set {
+	set := &Set{}
+	for _, r := range rules {
+		compiled, err := compile(r)
+		if err != nil {
+			slog.Error("skipped invalid filter rule", "rule_id", r.ID, "error", err)
+			continue
+		}
+		set.rules = append(set.rules, compiled)
+	}
+
+	return set
+}
+
+// Validate checks the action and the condition of the rule before it is stored.
+func Validate(r model.FilterRule) error {
+	_, err := compile(r)
+	return err
+}
+
+func compile(r model.FilterRule) (rule, error) {
+	if r.Action != model.FilterActionInclude && r.Action != model.FilterActionExclude {
+		return rule{}, fmt.Errorf("unknown action %q", r.Action)
+	}
+
+	matches, err := Parse(r.Condition)
+	if err != nil {
+		return rule{}, err
+	}
+
+	return rule{FilterRule: r, matches: matches}, nil
+}
+
+// Allows reports whether the item of the source passes the rules.
+func (s *Set) Allows(sourceID int64, item model.Item) bool {
+	hasInclude, included := false, false
+	for _, r := range s.rules {
+		if r.SourceID != 0 && r.SourceID != sourceID {
+			continue
+		}
+
+		switch r.Action {
+		case model.FilterActionExclude:
+			if r.matches(item) {
+				return false
+			}
+		case model.FilterActionInclude:
+			hasInclude = true
+			if !included && r.matches(item) {
+				included = true
+			}
+		}
+	}
+
+	return !hasInclude || included
+}
+
+// Engine loads the rules from the storage on top of the static keyword rules.
+type Engine struct {
+	storage  RuleStorage
+	keywords []model.FilterRule
+}
+
+// NewEngine creates an engine. The keywords exclude the items whose title
+// contains one of them or that have one of them as a category.
+func NewEngine(storage RuleStorage, keywords []string) *Engine {
+	return &Engine{
+		storage:  storage,
+		keywords: KeywordRules(keywords),
+	}
+}
+
+// Load compiles the current rules. It is called per fetch, so that the rules
+// added in the bot apply without a restart.
+func (e *Engine) Load(ctx context.Context) (*Set, error) {
+	stored, err := e.storage.GetFilterRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSet(append(append([]model.FilterRule(nil), e.keywords...), stored...)), nil
+}
+
+// KeywordRules turns a flat keyword list into global exclude rules.
+func KeywordRules(keywords []string) []model.FilterRule {
+	rules := make([]model.FilterRule, 0, len(keywords))
+	for _, keyword := range keywords {
+		if keyword == "" {
+			continue
+		}
+
+		condition := Condition{Any: []Condition{
+			{Field: FieldTitle, Match: MatchContains, Value: keyword},
+			{Field: FieldCategory, Match: MatchEquals, Value: keyword},
+		}}
+		raw, err := json.Marshal(condition)
+		if err != nil {
+			continue
+		}
+
+		rules = append(rules, model.FilterRule{Action: model.FilterActionExclude, Condition: raw})
+	}
+
+	return rules
+}
@@ -0,0 +1,170 @@
+package filter
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+func exclude(sourceID int64, condition string) model.FilterRule {
+	return model.FilterRule{SourceID: sourceID, Action: model.FilterActionExclude, Condition: json.RawMessage(condition)}
+}
+
+func include(sourceID int64, condition string) model.FilterRule {
+	return model.FilterRule{SourceID: sourceID, Action: model.FilterActionInclude, Condition: json.RawMessage(condition)}
+}
+
+func TestSetAllows(t *testing.T) {
+	goRelease := model.Item{Title: "Go 1.25 is released", Categories: []string{"golang"}}
+	sponsored := model.Item{Title: "Sponsored: learn Go in a week", Categories: []string{"ads"}}
+	rustRelease := model.Item{Title: "Rust 1.90 is released", Categories: []string{"rust"}}
+
+	tests := []struct {
+		name     string
+		rules    []model.FilterRule
+		sourceID int64
+		item     model.Item
+		want     bool
+	}{
+		{name: "no rules", sourceID: 1, item: goRelease, want: true},
+		{
+			name:     "global exclude",
+			rules:    []model.FilterRule{exclude(0, `{"field": "title", "match": "word", "value": "sponsored"}`)},
+			sourceID: 1,
+			item:     sponsored,
+			want:     false,
+		},
+		{
+			name:     "exclude of the source",
+			rules:    []model.FilterRule{exclude(1, `{"field": "category", "match": "equals", "value": "ads"}`)},
+			sourceID: 1,
+			item:     sponsored,
+			want:     false,
+		},
+		{
+			name:     "exclude of another source",
+			rules:    []model.FilterRule{exclude(2, `{"field": "category", "match": "equals", "value": "ads"}`)},
+			sourceID: 1,
+			item:     sponsored,
+			want:     true,
+		},
+		{
+			name:     "include of the source matches",
+			rules:    []model.FilterRule{include(1, `{"field": "title", "match": "regex", "value": "^Go "}`)},
+			sourceID: 1,
+			item:     goRelease,
+			want:     true,
+		},
+		{
+			name:     "include of the source misses",
+			rules:    []model.FilterRule{include(1, `{"field": "title", "match": "regex", "value": "^Go "}`)},
+			sourceID: 1,
+			item:     rustRelease,
+			want:     false,
+		},
+		{
+			name:     "include of another source does not apply",
+			rules:    []model.FilterRule{include(2, `{"field": "title", "match": "regex", "value": "^Go "}`)},
+			sourceID: 1,
+			item:     rustRelease,
+			want:     true,
+		},
+		{
+			name: "any of the includes",
+			rules: []model.FilterRule{
+				include(1, `{"field": "category", "value": "golang"}`),
+				include(0, `{"field": "category", "value": "rust"}`),
+			},
+			sourceID: 1,
+			item:     rustRelease,
+			want:     true,
+		},
+		{
+			name: "exclude wins over include",
+			rules: []model.FilterRule{
+				include(1, `{"field": "title", "match": "word", "value": "go"}`),
+				exclude(0, `{"field": "title", "match": "word", "value": "sponsored"}`),
+			},
+			sourceID: 1,
+			item:     sponsored,
+			want:     false,
+		},
+		{
+			name: "invalid rule is left out",
+			rules: []model.FilterRule{
+				exclude(0, `{"field": "title", "match": "regex", "value": "("}`),
+				include(0, `{"field": "body", "value": "go"}`),
+			},
+			sourceID: 1,
+			item:     goRelease,
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewSet(tt.rules).Allows(tt.sourceID, tt.item); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    model.FilterRule
+		wantErr bool
+	}{
+		{name: "exclude", rule: exclude(0, `{"field": "title", "value": "go"}`)},
+		{name: "include", rule: include(1, `{"field": "title", "value": "go"}`)},
+		{name: "unknown action", rule: model.FilterRule{Action: "drop", Condition: json.RawMessage(`{"field": "title", "value": "go"}`)}, wantErr: true},
+		{name: "invalid condition", rule: exclude(0, `{"field": "title", "match": "regex", "value": "("}`), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.rule); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type staticRules []model.FilterRule
+
+func (r staticRules) GetFilterRules(context.Context) ([]model.FilterRule, error) {
+	return r, nil
+}
+
+func TestEngineLoad(t *testing.T) {
+	engine := NewEngine(staticRules{exclude(1, `{"field": "author", "match": "equals", "value": "bot"}`)}, []string{"crypto", ""})
+
+	set, err := engine.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		sourceID int64
+		item     model.Item
+		want     bool
+	}{
+		{name: "keyword in the title", sourceID: 2, item: model.Item{Title: "Crypto prices fall"}, want: false},
+		{name: "keyword as a category", sourceID: 2, item: model.Item{Title: "Markets", Categories: []string{"CRYPTO"}}, want: false},
+		{name: "keyword inside a category", sourceID: 2, item: model.Item{Title: "Markets", Categories: []string{"cryptography"}}, want: true},
+		{name: "stored rule of the source", sourceID: 1, item: model.Item{Title: "Daily digest", Author: "Bot"}, want: false},
+		{name: "stored rule of another source", sourceID: 2, item: model.Item{Title: "Daily digest", Author: "Bot"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := set.Allows(tt.sourceID, tt.item); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package htmlutil
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HasToken reports whether the space separated attribute value, such as the
+// rel of a link, contains the token.
+func HasToken(value string, token string) bool {
+	for _, field := range strings.Fields(value) {
+		if field == token {
+			return true
+		}
+	}
+	return false
+}
+
+// PlainText drops the markup of an HTML snippet, such as a feed summary.
+// Text without tags is returned as it is.
+func PlainText(text string) string {
+	if !strings.Contains(text, "<") {
+		return text
+	}
+
+	var b strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(text))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return b.String()
+		case html.TextToken:
+			b.Write(tokenizer.Text())
+			b.WriteByte(' ')
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package language
+
+import (
+	"strings"
+
+	"github.com/abadojack/whatlanggo"
+	"github.com/ozaitsev92/gonewsbot/internal/htmlutil"
+)
+
+// minConfidence is lower than the "reliable" threshold of whatlanggo, which
+// a title and a short teaser rarely reach even when the guess is right.
+const minConfidence = 0.5
+
+// Detect returns the ISO 639-1 code of the language of the title and the
+// summary, or an empty string when the language cannot be told.
+func Detect(title string, summary string) string {
+	info := whatlanggo.Detect(title + "\n" + htmlutil.PlainText(summary))
+	if info.Lang < 0 || info.Confidence < minConfidence {
+		return ""
+	}
+
+	return info.Lang.Iso6391()
+}
+
+// Allowed reports whether a language passes the list of allowed languages.
+// An empty list allows everything, and so does an undetected language:
+// a short title is not enough to drop an item.
+func Allowed(code string, allowed []string) bool {
+	if code == "" || len(allowed) == 0 {
+		return true
+	}
+
+	for _, language := range allowed {
+		if strings.EqualFold(language, code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Name returns the English name of the language for the prompts, or the code
+// itself when it is unknown.
+func Name(code string) string {
+	if code == "" {
+		return ""
+	}
+
+	for lang, name := range whatlanggo.Langs {
+		if lang.Iso6391() == strings.ToLower(code) {
+			return name
+		}
+	}
+
+	return code
+}
@@ -0,0 +1,156 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// releaseTimeout bounds the release of the lock on the way out.
+const releaseTimeout = 5 * time.Second
+
+// Lock is a lock that dies with its holder, e.g. a Postgres advisory lock.
+type Lock interface {
+	TryAcquire(ctx context.Context) (bool, error)
+	// Check fails once the lock may have been lost.
+	Check(ctx context.Context) error
+	Release(ctx context.Context) error
+}
+
+// Elector runs a function on exactly one of the instances that share a lock.
+// Every instance has an Elector with a lock of its own on the same key.
+type Elector struct {
+	lock     Lock
+	interval time.Duration
+}
+
+// New creates an elector that tries to take the lock and checks that it still
+// holds it every interval. The interval bounds how long a failover takes.
+func New(lock Lock, interval time.Duration) *Elector {
+	return &Elector{
+		lock:     lock,
+		interval: interval,
+	}
+}
+
+// Run calls lead whenever this instance becomes the leader, until the context
+// is cancelled. The context of lead is cancelled when the lock is lost, and the
+// lock is released when lead returns, so that another instance takes over.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context) error) error {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		acquired, err := e.lock.TryAcquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("failed to acquire leader lock", "error", err)
+		}
+
+		if acquired {
+			slog.Info("became the leader")
+
+			err := e.lead(ctx, lead)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			slog.Warn("gave up the leadership", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) lead(ctx context.Context, lead func(ctx context.Context) error) error {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- lead(leaderCtx)
+	}()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	var err error
+loop:
+	for {
+		select {
+		case err = <-done:
+			break loop
+		case <-ticker.C:
+			if err = e.lock.Check(ctx); err != nil {
+				// Another instance may be the leader already, stop before doing anything twice.
+				cancel()
+				<-done
+				break loop
+			}
+		case <-ctx.Done():
+			err = <-done
+			break loop
+		}
+	}
+
+	releaseCtx, releaseCancel := context.WithTimeout(context.WithoutCancel(ctx), releaseTimeout)
+	defer releaseCancel()
+
+	if releaseErr := e.lock.Release(releaseCtx); releaseErr != nil {
+		slog.Error("failed to release leader lock", "error", releaseErr)
+	}
+
+	return err
+}
+
+// Worker is a part of the work of the leader.
+type Worker struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Together runs the workers as one lead function. Once any of them returns,
+// the others are cancelled and the leadership is given up, rather than kept by
+// an instance that does only a part of the work.
+func Together(workers ...Worker) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if len(workers) == 0 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+
+		workersCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stopped := make(chan error, len(workers))
+		for _, w := range workers {
+			go func() {
+				err := w.Run(workersCtx)
+				if err == nil {
+					err = errors.New("returned")
+				} else if workersCtx.Err() == nil {
+					slog.Error("leader worker stopped with error", "worker", w.Name, "error", err)
+				}
+				stopped <- fmt.Errorf("%s stopped: %w", w.Name, err)
+			}()
+		}
+
+		// The first worker to stop takes the others down with it.
+		err := <-stopped
+		cancel()
+		for range len(workers) - 1 {
+			<-stopped
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,209 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testInterval = 5 * time.Millisecond
+
+// sharedKey is the key all the instances of a test lock on, like the key of
+// a Postgres advisory lock.
+type sharedKey struct {
+	mu     sync.Mutex
+	holder *memoryLock
+}
+
+// memoryLock is the lock of one instance on the shared key.
+type memoryLock struct {
+	key      *sharedKey
+	released atomic.Int32
+}
+
+func (l *memoryLock) TryAcquire(ctx context.Context) (bool, error) {
+	// A query on a cancelled context fails like this.
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	l.key.mu.Lock()
+	defer l.key.mu.Unlock()
+
+	if l.key.holder == nil {
+		l.key.holder = l
+	}
+
+	return l.key.holder == l, nil
+}
+
+func (l *memoryLock) Check(_ context.Context) error {
+	l.key.mu.Lock()
+	defer l.key.mu.Unlock()
+
+	if l.key.holder != l {
+		return errors.New("lock lost")
+	}
+
+	return nil
+}
+
+func (l *memoryLock) Release(_ context.Context) error {
+	l.key.mu.Lock()
+	defer l.key.mu.Unlock()
+
+	if l.key.holder == l {
+		l.key.holder = nil
+		l.released.Add(1)
+	}
+
+	return nil
+}
+
+// leaders counts the instances that lead at once.
+type leaders struct {
+	active  atomic.Int32
+	maximum atomic.Int32
+	terms   atomic.Int32
+}
+
+func (l *leaders) begin() {
+	l.terms.Add(1)
+	active := l.active.Add(1)
+	for {
+		maximum := l.maximum.Load()
+		if active <= maximum || l.maximum.CompareAndSwap(maximum, active) {
+			return
+		}
+	}
+}
+
+func (l *leaders) end() {
+	l.active.Add(-1)
+}
+
+// runInstances runs the electors of n instances on one key until the context is done.
+func runInstances(ctx context.Context, n int, lead func(ctx context.Context) error) []*memoryLock {
+	key := &sharedKey{}
+	locks := make([]*memoryLock, n)
+
+	var wg sync.WaitGroup
+	for i := range locks {
+		locks[i] = &memoryLock{key: key}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = New(locks[i], testInterval).Run(ctx, lead)
+		}()
+	}
+	wg.Wait()
+
+	return locks
+}
+
+func TestElectorSingleLeader(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var counter leaders
+	runInstances(ctx, 3, func(ctx context.Context) error {
+		counter.begin()
+		defer counter.end()
+
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if terms := counter.terms.Load(); terms != 1 {
+		t.Errorf("leadership terms = %d, want 1, the leader keeps the lock until it stops", terms)
+	}
+	if maximum := counter.maximum.Load(); maximum != 1 {
+		t.Errorf("instances leading at once = %d, want 1", maximum)
+	}
+}
+
+func TestElectorFailoverWhenWorkerStops(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	var counter leaders
+	lead := Together(
+		Worker{Name: "bot", Run: func(ctx context.Context) error {
+			// The bot gives up shortly after every instance becomes the leader.
+			select {
+			case <-time.After(3 * testInterval):
+				return errors.New("unauthorized")
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}},
+		Worker{Name: "fetcher", Run: func(ctx context.Context) error {
+			counter.begin()
+			defer counter.end()
+
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	)
+
+	locks := runInstances(ctx, 3, lead)
+
+	if terms := counter.terms.Load(); terms < 2 {
+		t.Errorf("leadership terms = %d, want the other instances to take over", terms)
+	}
+	if maximum := counter.maximum.Load(); maximum != 1 {
+		t.Errorf("instances leading at once = %d, want 1", maximum)
+	}
+
+	var released int32
+	for _, lock := range locks {
+		released += lock.released.Load()
+	}
+	if terms := counter.terms.Load(); released != terms {
+		t.Errorf("lock released %d times, want once per each of the %d terms", released, terms)
+	}
+}
+
+func TestTogether(t *testing.T) {
+	cancelled := make(chan struct{})
+	lead := Together(
+		Worker{Name: "bot", Run: func(context.Context) error {
+			return errors.New("unauthorized")
+		}},
+		Worker{Name: "fetcher", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		}},
+	)
+
+	err := lead(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "bot stopped: unauthorized") {
+		t.Fatalf("Together() error = %v, want the error of the bot", err)
+	}
+
+	select {
+	case <-cancelled:
+	default:
+		t.Fatal("the fetcher kept running after the bot stopped")
+	}
+}
+
+func TestTogetherParentCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	lead := Together(Worker{Name: "fetcher", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	if err := lead(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Together() error = %v, want %v", err, context.Canceled)
+	}
+}
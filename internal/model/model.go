@@ -1,6 +1,42 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrSourceExists is returned when a source with the same feed URL is stored already.
+var ErrSourceExists = errors.New("source already exists")
+
+const (
+	SourceTypeRSS        = "rss"
+	SourceTypeAtom       = "atom"
+	SourceTypeJSONFeed   = "jsonfeed"
+	SourceTypeHTML       = "html"
+	SourceTypeHackerNews = "hackernews"
+	SourceTypeReddit     = "reddit"
+	SourceTypeGitHub     = "github_releases"
+	SourceTypePodcast    = "podcast"
+	SourceTypeYouTube    = "youtube"
+	SourceTypeMastodon   = "mastodon"
+	SourceTypeIMAP       = "imap"
+	// SourceTypePush sources are never fetched, external systems submit their articles.
+	SourceTypePush = "push"
+)
+
+// PushSourceURL is the feed URL that identifies the push source with the name.
+func PushSourceURL(name string) string {
+	return "push:" + name
+}
+
+// IsWebLink tells whether a link of an item can be opened in a browser. The
+// items without one, e.g. newsletters without a web version, carry a mid: URL
+// (RFC 2392) that only keeps them unique and is never posted.
+func IsWebLink(link string) bool {
+	return strings.HasPrefix(link, "https://") || strings.HasPrefix(link, "http://")
+}
 
 type Item struct {
 	Title      string
@@ -8,24 +44,195 @@ type Item struct {
 	Link       string
 	Date       time.Time
 	Summary    string
+	Content    string
+	Author     string
+	Enclosures []Enclosure
+	// Duration and Thumbnail describe the media of podcast episodes and videos.
+	Duration  time.Duration
+	Thumbnail string
+	// Score and Comments are the points and the comment count on sites that rank submissions.
+	Score      int
+	Comments   int
 	SourceName string
+	// Language is the ISO 639-1 code the fetcher detected, empty when unknown.
+	Language string
+}
+
+type Enclosure struct {
+	URL    string
+	Type   string
+	Length int64
 }
 
 type Source struct {
+	ID       int64
+	Name     string
+	FeedURL  string
+	Type     string
+	Category string
+	Priority int
+	// Options holds the settings specific to the source type, e.g. the CSS selectors of an HTML source.
+	Options      json.RawMessage
+	ETag         string
+	LastModified string
+	// Cursor is the position a paging source resumes from, e.g. the since_id of a Mastodon timeline.
+	Cursor string
+	// Languages are the ISO 639-1 codes allowed for the source, they override the global list when set.
+	Languages []string
+	// FetchInterval overrides the global fetch interval when it is not zero.
+	FetchInterval       time.Duration
+	NextFetchAt         time.Time
+	ConsecutiveFailures int
+	LastError           string
+	Enabled             bool
+	DisabledReason      string
+	LastItemAt          time.Time
+	CreatedAt           time.Time
+}
+
+const (
+	FetchStatusOK          = "ok"
+	FetchStatusNotModified = "not_modified"
+	FetchStatusError       = "error"
+)
+
+// Error classes of the failed fetch runs.
+const (
+	FetchErrorDNS        = "dns"
+	FetchErrorTimeout    = "timeout"
+	FetchErrorHTTPStatus = "http_status"
+	FetchErrorNetwork    = "network"
+	FetchErrorParse      = "parse"
+	FetchErrorDB         = "db"
+	FetchErrorConfig     = "config"
+)
+
+type FetchRun struct {
+	ID           int64
+	SourceID     int64
+	Status       string
+	HTTPStatus   int
+	ItemCount    int
+	NewItemCount int
+	Duration     time.Duration
+	Error        string
+	ErrorClass   string
+	StartedAt    time.Time
+}
+
+// FetchReport summarizes one run of the fetcher over the due sources.
+type FetchReport struct {
+	ID               int64
+	StartedAt        time.Time
+	Duration         time.Duration
+	SourcesAttempted int
+	SourcesSucceeded int
+	SourcesFailed    int
+	ItemsSeen        int
+	ItemsInserted    int
+	ItemsDuplicate   int
+	ItemsFiltered    int
+	// Errors counts the failed sources by error class.
+	Errors map[string]int
+}
+
+// SourceHealth summarizes the recent fetch runs of a source.
+type SourceHealth struct {
+	Source     Source
+	LastRun    *FetchRun
+	RunsTotal  int
+	RunsFailed int
+}
+
+const (
+	WebSubStatePending = "pending"
+	WebSubStateActive  = "active"
+	WebSubStateDenied  = "denied"
+)
+
+// WebSubSubscription is the subscription of a source at the WebSub hub of its feed.
+type WebSubSubscription struct {
+	SourceID       int64
+	HubURL         string
+	TopicURL       string
+	Secret         string
+	State          string
+	LeaseExpiresAt time.Time
+	UpdatedAt      time.Time
+}
+
+const (
+	FilterActionInclude = "include"
+	FilterActionExclude = "exclude"
+)
+
+// FilterRule decides which fetched items are stored. Condition is the JSON
+// condition tree the filter package evaluates. Rules with a SourceID of 0
+// apply to every source.
+type FilterRule struct {
 	ID        int64
-	Name      string
-	FeedURL   string
-	Priority  int
+	SourceID  int64
+	Action    string
+	Condition json.RawMessage
 	CreatedAt time.Time
 }
 
+// The states of an article on its way to the channel: a new article is
+// claimed by the notifier, summarized and sent. An article that failed too
+// many times, or whose sending was interrupted, is failed and waits for an
+// admin to retry, skip or post it. A failed article an admin posts as it is
+// stays posting until it is sent, the notifier never picks it up.
+const (
+	ArticleStateNew        = "new"
+	ArticleStateClaimed    = "claimed"
+	ArticleStateSummarized = "summarized"
+	ArticleStatePosting    = "posting"
+	ArticleStateSent       = "sent"
+	ArticleStateFailed     = "failed"
+	ArticleStateSkipped    = "skipped"
+)
+
 type Article struct {
-	ID          int64
-	SourceID    int64
-	Title       string
-	Link        string
-	Summary     string
-	PublishedAt time.Time
-	PostedAt    time.Time
-	CreatedAt   time.Time
+	ID             int64
+	SourceID       int64
+	SourceName     string
+	SourcePriority int
+	Title          string
+	// Link is the link as the source had it, CanonicalLink the normalized one the articles are deduplicated on.
+	Link          string
+	CanonicalLink string
+	Summary       string
+	// EnclosureURL, Duration and Thumbnail are set for podcast episodes and videos.
+	EnclosureURL string
+	Duration     time.Duration
+	Thumbnail    string
+	PublishedAt  time.Time
+	PostedAt     time.Time
+	CreatedAt    time.Time
+	// Language is the detected language of the article. TranslateTo is set for
+	// articles in a language that is not allowed, the summary is written in it.
+	Language    string
+	TranslateTo string
+	// State is one of the ArticleState constants, Attempts and LastError tell about the failed posting attempts.
+	State     string
+	Attempts  int
+	LastError string
+	// ClusterID is the story cluster of the article, 0 until it is assigned.
+	ClusterID int64
+	// AlsoCoveredBy lists the other articles of the story cluster that are not posted on their own.
+	AlsoCoveredBy []Coverage
+}
+
+// Coverage is an article of another source about the same story.
+type Coverage struct {
+	SourceName string
+	Link       string
+}
+
+// Fingerprint is the SimHash of a recent article used to find its story cluster.
+type Fingerprint struct {
+	ArticleID int64
+	SourceID  int64
+	ClusterID int64
+	SimHash   uint64
 }
@@ -2,46 +2,85 @@ package notifier
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-shiori/go-readability"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/ozaitsev92/gonewsbot/internal/botkit/markup"
+	"github.com/ozaitsev92/gonewsbot/internal/language"
 	"github.com/ozaitsev92/gonewsbot/internal/model"
 )
 
+const (
+	// maxCaptionLength is the Telegram limit for photo captions.
+	maxCaptionLength = 1024
+	// maxCoverageLinks caps the "also covered by" links of a post.
+	maxCoverageLinks = 5
+	// claimCandidates is how many ranked articles are tried when the best ones are taken.
+	claimCandidates = 5
+	// claimLease outlasts the summarizer timeout and the sending.
+	claimLease = 15 * time.Minute
+	// maxRetryShift caps the doubling of the retry delay.
+	maxRetryShift = 6
+)
+
 type ArticlesProvider interface {
 	AllNotPosted(ctx context.Context, since time.Time, limit uint64) ([]model.Article, error)
 	MarkPosted(ctx context.Context, id int64) error
 }
 
+// PostOutbox moves the articles through their states, see model.ArticleStateNew.
+type PostOutbox interface {
+	ClaimArticle(ctx context.Context, id int64, until time.Time) (bool, string, error)
+	MarkSummarized(ctx context.Context, id int64, summary string) error
+	RecordPostFailure(ctx context.Context, id int64, lastError string, retryAt time.Time, maxAttempts int) (string, error)
+	FailInterruptedPosts(ctx context.Context, now time.Time) (int64, error)
+	ClaimFailedArticle(ctx context.Context, id int64, until time.Time) (model.Article, bool, error)
+}
+
 type Summarizer interface {
 	Summarize(text string) (string, error)
 }
 
+// TranslatingSummarizer is implemented by summarizers that can write the
+// summary in another language than the one of the article.
+type TranslatingSummarizer interface {
+	Summarizer
+	SummarizeIn(text string, language string) (string, error)
+}
+
 type Notifier struct {
 	articles         ArticlesProvider
+	outbox           PostOutbox
 	summarizer       Summarizer
 	bot              *tgbotapi.BotAPI
 	sendInterval     time.Duration
 	lookupTimeWindow time.Duration
 	channelID        int64
+	maxAttempts      int
+	retryDelay       time.Duration
 	httpClient       *http.Client
 }
 
 func NewNotifier(
 	articles ArticlesProvider,
+	outbox PostOutbox,
 	summarizer Summarizer,
 	bot *tgbotapi.BotAPI,
 	sendInterval time.Duration,
 	lookupTimeWindow time.Duration,
 	channelID int64,
+	maxAttempts int,
+	retryDelay time.Duration,
 ) *Notifier {
 	// todo: get these values from config
 	client := &http.Client{
@@ -61,11 +100,14 @@ func NewNotifier(
 
 	return &Notifier{
 		articles:         articles,
+		outbox:           outbox,
 		summarizer:       summarizer,
 		bot:              bot,
 		sendInterval:     sendInterval,
 		lookupTimeWindow: lookupTimeWindow,
 		channelID:        channelID,
+		maxAttempts:      maxAttempts,
+		retryDelay:       retryDelay,
 		httpClient:       client,
 	}
 }
@@ -74,15 +116,17 @@ func (n *Notifier) Start(ctx context.Context) error {
 	ticker := time.NewTicker(n.sendInterval)
 	defer ticker.Stop()
 
+	// A failed round is retried on the next tick, the failures of single
+	// articles are recorded on them and never stop the loop.
 	if err := n.SelectAndSendArticle(ctx); err != nil {
-		return err
+		slog.Error("failed to select and send article", "error", err)
 	}
 
 	for {
 		select {
 		case <-ticker.C:
 			if err := n.SelectAndSendArticle(ctx); err != nil {
-				return err
+				slog.Error("failed to select and send article", "error", err)
 			}
 		case <-ctx.Done():
 			return ctx.Err()
@@ -90,26 +134,54 @@ func (n *Notifier) Start(ctx context.Context) error {
 	}
 }
 
-// todo: wrap the body of this method in a transaction
+// SelectAndSendArticle claims the best ranked article and posts it. The
+// summary is stored before the sending, so that a failed sending is retried
+// without summarizing again. An article whose sending was interrupted is
+// failed rather than sent twice.
 func (n *Notifier) SelectAndSendArticle(ctx context.Context) error {
-	topOneArticles, err := n.articles.AllNotPosted(ctx, time.Now().Add(-n.lookupTimeWindow), 1)
+	failed, err := n.outbox.FailInterruptedPosts(ctx, time.Now())
 	if err != nil {
 		return err
 	}
-
-	if len(topOneArticles) == 0 {
-		return nil
+	if failed > 0 {
+		slog.Warn("failed articles whose sending was interrupted", "count", failed)
 	}
 
-	article := topOneArticles[0]
-
-	summary, err := n.extractSummary(ctx, article)
+	candidates, err := n.articles.AllNotPosted(ctx, time.Now().Add(-n.lookupTimeWindow), claimCandidates)
 	if err != nil {
 		return err
 	}
 
+	for _, article := range candidates {
+		claimed, summary, err := n.outbox.ClaimArticle(ctx, article.ID, time.Now().Add(claimLease))
+		if err != nil {
+			return err
+		}
+		if claimed {
+			return n.postArticle(ctx, article, summary)
+		}
+	}
+
+	return nil
+}
+
+// postArticle summarizes and sends the claimed article. The summary of an
+// earlier attempt is reused.
+func (n *Notifier) postArticle(ctx context.Context, article model.Article, summary string) error {
+	if summary == "" {
+		var err error
+		summary, err = n.extractSummary(ctx, article)
+		if err != nil {
+			return n.recordFailure(ctx, article, fmt.Errorf("summarize: %w", err))
+		}
+
+		if err := n.outbox.MarkSummarized(ctx, article.ID, summary); err != nil {
+			return err
+		}
+	}
+
 	if err := n.sendArticle(article, summary); err != nil {
-		return err
+		return n.recordFailure(ctx, article, fmt.Errorf("send: %w", err))
 	}
 
 	if err := n.articles.MarkPosted(ctx, article.ID); err != nil {
@@ -119,6 +191,51 @@ func (n *Notifier) SelectAndSendArticle(ctx context.Context) error {
 	return nil
 }
 
+// PostWithoutSummary posts a failed article as it is, without asking the
+// summarizer. The article is posting meanwhile, which the notifier leaves
+// alone, and is failed again when the sending is interrupted. It reports false
+// when the article is not failed anymore. When the sending fails, the article
+// stays failed with the new error.
+func (n *Notifier) PostWithoutSummary(ctx context.Context, id int64) (bool, error) {
+	article, claimed, err := n.outbox.ClaimFailedArticle(ctx, id, time.Now().Add(claimLease))
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	if err := n.sendArticle(article, ""); err != nil {
+		// No attempts are left, so the article goes back to failed.
+		if _, recordErr := n.outbox.RecordPostFailure(ctx, id, "send: "+err.Error(), time.Now(), 0); recordErr != nil {
+			slog.Error("failed to record post failure", "article_id", id, "error", recordErr)
+		}
+		return false, err
+	}
+
+	if err := n.articles.MarkPosted(ctx, id); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// recordFailure releases the article for a retry after a delay that doubles
+// with every attempt. It returns an error only when the failure cannot be stored.
+func (n *Notifier) recordFailure(ctx context.Context, article model.Article, postErr error) error {
+	retryAt := time.Now().Add(n.retryDelay << min(article.Attempts, maxRetryShift))
+
+	state, err := n.outbox.RecordPostFailure(ctx, article.ID, postErr.Error(), retryAt, n.maxAttempts)
+	if err != nil {
+		return err
+	}
+
+	if state == model.ArticleStateFailed {
+		slog.Error("gave up posting article", "article_id", article.ID, "attempts", article.Attempts+1, "error", postErr)
+	} else {
+		slog.Warn("failed to post article, will retry", "article_id", article.ID, "retry_at", retryAt, "error", postErr)
+	}
+
+	return nil
+}
+
 func (n *Notifier) extractSummary(ctx context.Context, article model.Article) (string, error) {
 	var r io.Reader
 
@@ -147,7 +264,12 @@ func (n *Notifier) extractSummary(ctx context.Context, article model.Article) (s
 		return "", err
 	}
 
-	summary, err := n.summarizer.Summarize(cleanText(doc.TextContent))
+	var summary string
+	if translator, ok := n.summarizer.(TranslatingSummarizer); ok && article.TranslateTo != "" {
+		summary, err = translator.SummarizeIn(cleanText(doc.TextContent), language.Name(article.TranslateTo))
+	} else {
+		summary, err = n.summarizer.Summarize(cleanText(doc.TextContent))
+	}
 	if err != nil {
 		return "", err
 	}
@@ -156,17 +278,21 @@ func (n *Notifier) extractSummary(ctx context.Context, article model.Article) (s
 }
 
 func (n *Notifier) sendArticle(article model.Article, summary string) error {
-	const msgFormat = "*%s*%s\n\n%s"
-
-	msg := tgbotapi.NewMessage(
-		n.channelID,
-		fmt.Sprintf(
-			msgFormat,
-			markup.EscapeForMarkdown(article.Title),
-			markup.EscapeForMarkdown(summary),
-			article.Link,
-		),
-	)
+	if article.Thumbnail != "" {
+		err := n.sendMediaArticle(article, summary)
+		if err == nil {
+			return nil
+		}
+
+		// Telegram rejects thumbnails it cannot download, the article is still worth posting as text.
+		// Any other error may have come after Telegram posted the photo, posting the text would repeat it.
+		if !isRejectedRequest(err) {
+			return err
+		}
+		slog.Warn("failed to send article with thumbnail", "article_id", article.ID, "thumbnail", article.Thumbnail, "error", err)
+	}
+
+	msg := tgbotapi.NewMessage(n.channelID, formatArticle(article, summary))
 	msg.ParseMode = tgbotapi.ModeMarkdownV2
 
 	_, err := n.bot.Send(msg)
@@ -177,6 +303,93 @@ func (n *Notifier) sendArticle(article model.Article, summary string) error {
 	return nil
 }
 
+// sendMediaArticle posts the thumbnail with the article as its caption.
+// The summary is shortened to fit the caption limit of Telegram.
+func (n *Notifier) sendMediaArticle(article model.Article, summary string) error {
+	caption := formatArticle(article, summary)
+	if overflow := utf8.RuneCountInString(caption) - maxCaptionLength; overflow > 0 {
+		caption = formatArticle(article, truncateRunes(summary, utf8.RuneCountInString(summary)-overflow-1)+"…")
+	}
+
+	photo := tgbotapi.NewPhoto(n.channelID, tgbotapi.FileURL(article.Thumbnail))
+	photo.Caption = caption
+	photo.ParseMode = tgbotapi.ModeMarkdownV2
+
+	_, err := n.bot.Send(photo)
+	return err
+}
+
+// isRejectedRequest tells whether Telegram answered the request with a Bad
+// Request error, rather than failing to answer it or asking to retry later.
+func isRejectedRequest(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest
+}
+
+func formatArticle(article model.Article, summary string) string {
+	const msgFormat = "*%s*%s%s"
+
+	var media string
+	if article.Duration > 0 {
+		media += "\n⏱ " + markup.EscapeForMarkdown(formatDuration(article.Duration))
+	}
+	link := article.CanonicalLink
+	if link == "" {
+		link = article.Link
+	}
+
+	if article.EnclosureURL != "" && article.EnclosureURL != article.Link && article.EnclosureURL != link {
+		media += "\n🎧 " + markup.EscapeForMarkdown(article.EnclosureURL)
+	}
+
+	text := fmt.Sprintf(
+		msgFormat,
+		markup.EscapeForMarkdown(article.Title),
+		media,
+		markup.EscapeForMarkdown(summary),
+	)
+	// A newsletter without a web version has nothing to link to.
+	if model.IsWebLink(link) {
+		text += "\n\n" + link
+	}
+
+	if len(article.AlsoCoveredBy) > 0 {
+		coverage := article.AlsoCoveredBy[:min(len(article.AlsoCoveredBy), maxCoverageLinks)]
+		links := make([]string, len(coverage))
+		for i, c := range coverage {
+			if model.IsWebLink(c.Link) {
+				links[i] = markup.Link(c.SourceName, c.Link)
+			} else {
+				links[i] = markup.EscapeForMarkdown(c.SourceName)
+			}
+		}
+		text += "\n\nAlso covered by: " + strings.Join(links, ", ")
+	}
+
+	return text
+}
+
+// formatDuration renders a duration as M:SS or H:MM:SS.
+func formatDuration(d time.Duration) string {
+	seconds := int(d.Round(time.Second) / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+func truncateRunes(text string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit])
+}
+
 func cleanText(text string) string {
 	return regexp.MustCompile(`\n{3,}`).ReplaceAllString(strings.TrimSpace(text), "\n")
 }
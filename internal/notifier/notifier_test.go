@@ -0,0 +1,229 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// serveBotAPI answers getMe and sendMessage like the Bot API does and
+// sendPhoto with the given handler. It counts the text messages sent.
+func serveBotAPI(t *testing.T, sendPhoto http.HandlerFunc) (*tgbotapi.BotAPI, *atomic.Int32) {
+	t.Helper()
+
+	var messages atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"id":1,"is_bot":true,"username":"newsbot"}}`)
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			messages.Add(1)
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"message_id":2,"chat":{"id":-100}}}`)
+		case strings.HasSuffix(r.URL.Path, "/sendPhoto"):
+			sendPhoto(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", srv.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatalf("NewBotAPIWithAPIEndpoint() error = %v", err)
+	}
+
+	return bot, &messages
+}
+
+func TestSendArticleThumbnailFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		sendPhoto    http.HandlerFunc
+		wantErr      bool
+		wantMessages int32
+	}{
+		{
+			name: "photo posted",
+			sendPhoto: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = io.WriteString(w, `{"ok":true,"result":{"message_id":1,"chat":{"id":-100}}}`)
+			},
+			wantMessages: 0,
+		},
+		{
+			name: "photo rejected",
+			sendPhoto: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = io.WriteString(w, `{"ok":false,"error_code":400,"description":"Bad Request: wrong file identifier/HTTP URL specified"}`)
+			},
+			wantMessages: 1,
+		},
+		{
+			name: "rate limited",
+			sendPhoto: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = io.WriteString(w, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 5","parameters":{"retry_after":5}}`)
+			},
+			wantErr:      true,
+			wantMessages: 0,
+		},
+		{
+			name: "connection lost",
+			sendPhoto: func(w http.ResponseWriter, _ *http.Request) {
+				// The photo may have been posted, the answer never arrives.
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					_ = conn.Close()
+				}
+			},
+			wantErr:      true,
+			wantMessages: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, messages := serveBotAPI(t, tt.sendPhoto)
+			n := &Notifier{bot: bot, channelID: -100}
+
+			err := n.sendArticle(model.Article{
+				ID:        1,
+				Title:     "Go 1.25 is released",
+				Link:      "https://go.dev/blog/go1.25",
+				Thumbnail: "https://go.dev/images/go1.25.png",
+			}, "A summary.")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sendArticle() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := messages.Load(); got != tt.wantMessages {
+				t.Errorf("text messages sent = %d, want %d", got, tt.wantMessages)
+			}
+		})
+	}
+}
+
+// memoryOutbox keeps the state of one failed article.
+type memoryOutbox struct {
+	PostOutbox
+
+	article     model.Article
+	maxAttempts int
+	posted      bool
+}
+
+func (o *memoryOutbox) ClaimFailedArticle(_ context.Context, id int64, _ time.Time) (model.Article, bool, error) {
+	if id != o.article.ID || o.article.State != model.ArticleStateFailed {
+		return model.Article{}, false, nil
+	}
+	o.article.State = model.ArticleStatePosting
+
+	return o.article, true, nil
+}
+
+func (o *memoryOutbox) RecordPostFailure(_ context.Context, _ int64, lastError string, _ time.Time, maxAttempts int) (string, error) {
+	o.maxAttempts = maxAttempts
+	o.article.State = model.ArticleStateFailed
+	o.article.LastError = lastError
+
+	return o.article.State, nil
+}
+
+func (o *memoryOutbox) AllNotPosted(context.Context, time.Time, uint64) ([]model.Article, error) {
+	return nil, nil
+}
+
+func (o *memoryOutbox) MarkPosted(context.Context, int64) error {
+	o.posted = true
+	o.article.State = model.ArticleStateSent
+
+	return nil
+}
+
+func TestPostWithoutSummary(t *testing.T) {
+	tests := []struct {
+		name      string
+		sendOK    bool
+		wantState string
+	}{
+		{name: "sent", sendOK: true, wantState: model.ArticleStateSent},
+		{name: "sending failed", sendOK: false, wantState: model.ArticleStateFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/getMe"):
+					_, _ = io.WriteString(w, `{"ok":true,"result":{"id":1,"is_bot":true,"username":"newsbot"}}`)
+				case tt.sendOK:
+					sent++
+					_, _ = io.WriteString(w, `{"ok":true,"result":{"message_id":2,"chat":{"id":-100}}}`)
+				default:
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = io.WriteString(w, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`)
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", srv.URL+"/bot%s/%s")
+			if err != nil {
+				t.Fatalf("NewBotAPIWithAPIEndpoint() error = %v", err)
+			}
+
+			outbox := &memoryOutbox{
+				article: model.Article{ID: 7, Title: "Go 1.25 is released", Link: "https://go.dev/blog/go1.25", State: model.ArticleStateFailed},
+				// RecordPostFailure sets the maximum, -1 tells that it was not called.
+				maxAttempts: -1,
+			}
+			n := &Notifier{articles: outbox, outbox: outbox, bot: bot, channelID: -100}
+
+			ok, err := n.PostWithoutSummary(context.Background(), 7)
+			if ok != tt.sendOK || (err == nil) != tt.sendOK {
+				t.Fatalf("PostWithoutSummary() = %v, %v, want %v", ok, err, tt.sendOK)
+			}
+			if outbox.article.State != tt.wantState {
+				t.Errorf("article state = %s, want %s", outbox.article.State, tt.wantState)
+			}
+			if !tt.sendOK && outbox.maxAttempts != 0 {
+				t.Errorf("failure recorded with %d attempts, want none left", outbox.maxAttempts)
+			}
+			if !tt.sendOK {
+				return
+			}
+			if sent != 1 {
+				t.Errorf("messages sent = %d, want 1", sent)
+			}
+
+			// The article is sent now, a second post finds nothing to do.
+			if ok, err := n.PostWithoutSummary(context.Background(), 7); ok || err != nil {
+				t.Errorf("second PostWithoutSummary() = %v, %v, want false", ok, err)
+			}
+		})
+	}
+}
+
+func TestFormatArticleWithoutWebLink(t *testing.T) {
+	text := formatArticle(model.Article{
+		Title: "Weekly digest",
+		Link:  "mid:digest-42@example.com",
+		AlsoCoveredBy: []model.Coverage{
+			{SourceName: "Other newsletter", Link: "mid:other@example.com"},
+			{SourceName: "Blog", Link: "https://example.com/digest"},
+		},
+	}, "\n\nThe news of the week.")
+
+	if strings.Contains(text, "mid:") {
+		t.Fatalf("formatArticle() = %q, want no mid: links", text)
+	}
+	if !strings.Contains(text, "Other newsletter") || !strings.Contains(text, "(https://example.com/digest)") {
+		t.Fatalf("formatArticle() = %q, want every source of the coverage and the web link", text)
+	}
+}
@@ -0,0 +1,269 @@
+package notifier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// outboxRow is an article with the outbox columns of the articles table.
+type outboxRow struct {
+	article      model.Article
+	summary      string
+	claimedUntil time.Time
+	retryAt      time.Time
+}
+
+// memoryStore moves the articles through their states the way the Postgres
+// storage does, see storage.ArticlePostgresStorage.
+type memoryStore struct {
+	rows map[int64]*outboxRow
+}
+
+func newMemoryStore(articles ...model.Article) *memoryStore {
+	s := &memoryStore{rows: make(map[int64]*outboxRow)}
+	for _, article := range articles {
+		article.State = model.ArticleStateNew
+		s.rows[article.ID] = &outboxRow{article: article}
+	}
+
+	return s
+}
+
+func (s *memoryStore) claimable(row *outboxRow, now time.Time) bool {
+	retryDue := row.retryAt.IsZero() || !row.retryAt.After(now)
+
+	switch row.article.State {
+	case model.ArticleStateNew:
+		return retryDue
+	case model.ArticleStateClaimed:
+		return row.claimedUntil.Before(now)
+	case model.ArticleStateSummarized:
+		return row.claimedUntil.IsZero() && retryDue
+	}
+
+	return false
+}
+
+func (s *memoryStore) AllNotPosted(_ context.Context, since time.Time, limit uint64) ([]model.Article, error) {
+	var articles []model.Article
+	for _, row := range s.rows {
+		if !s.claimable(row, time.Now()) {
+			continue
+		}
+		if row.article.PublishedAt.Before(since) && row.retryAt.IsZero() {
+			continue
+		}
+		articles = append(articles, row.article)
+	}
+
+	return articles[:min(uint64(len(articles)), limit)], nil
+}
+
+func (s *memoryStore) MarkPosted(_ context.Context, id int64) error {
+	row := s.rows[id]
+	row.article.State = model.ArticleStateSent
+	row.claimedUntil = time.Time{}
+
+	return nil
+}
+
+func (s *memoryStore) ClaimArticle(_ context.Context, id int64, until time.Time) (bool, string, error) {
+	row := s.rows[id]
+	if !s.claimable(row, time.Now()) {
+		return false, "", nil
+	}
+
+	if row.article.State != model.ArticleStateSummarized {
+		row.article.State = model.ArticleStateClaimed
+		row.summary = ""
+	}
+	row.claimedUntil = until
+
+	return true, row.summary, nil
+}
+
+func (s *memoryStore) MarkSummarized(_ context.Context, id int64, summary string) error {
+	row := s.rows[id]
+	row.article.State = model.ArticleStateSummarized
+	row.summary = summary
+
+	return nil
+}
+
+func (s *memoryStore) RecordPostFailure(_ context.Context, id int64, lastError string, retryAt time.Time, maxAttempts int) (string, error) {
+	row := s.rows[id]
+	row.article.Attempts++
+	row.article.LastError = lastError
+	row.retryAt = retryAt
+	row.claimedUntil = time.Time{}
+
+	switch {
+	case row.article.Attempts >= maxAttempts:
+		row.article.State = model.ArticleStateFailed
+	case row.article.State == model.ArticleStateClaimed:
+		row.article.State = model.ArticleStateNew
+	}
+
+	return row.article.State, nil
+}
+
+func (s *memoryStore) FailInterruptedPosts(_ context.Context, now time.Time) (int64, error) {
+	var failed int64
+	for _, row := range s.rows {
+		state := row.article.State
+		if (state == model.ArticleStateSummarized || state == model.ArticleStatePosting) &&
+			!row.claimedUntil.IsZero() && row.claimedUntil.Before(now) {
+			row.article.State = model.ArticleStateFailed
+			row.claimedUntil = time.Time{}
+			failed++
+		}
+	}
+
+	return failed, nil
+}
+
+func (s *memoryStore) ClaimFailedArticle(_ context.Context, id int64, until time.Time) (model.Article, bool, error) {
+	row := s.rows[id]
+	if row.article.State != model.ArticleStateFailed {
+		return model.Article{}, false, nil
+	}
+	row.article.State = model.ArticleStatePosting
+	row.claimedUntil = until
+
+	return row.article, true, nil
+}
+
+func (s *memoryStore) RetryFailedArticle(_ context.Context, id int64) (bool, error) {
+	row := s.rows[id]
+	if row.article.State != model.ArticleStateFailed {
+		return false, nil
+	}
+
+	row.article.State = model.ArticleStateNew
+	if row.summary != "" {
+		row.article.State = model.ArticleStateSummarized
+	}
+	row.article.Attempts = 0
+	row.retryAt = time.Now()
+	row.claimedUntil = time.Time{}
+
+	return true, nil
+}
+
+// age moves the article back in time, past its retry time and out of the publish window.
+func (s *memoryStore) age(id int64, by time.Duration) {
+	row := s.rows[id]
+	row.article.PublishedAt = row.article.PublishedAt.Add(-by)
+	if !row.retryAt.IsZero() {
+		row.retryAt = time.Now().Add(-time.Second)
+	}
+}
+
+type fixedSummarizer string
+
+func (s fixedSummarizer) Summarize(string) (string, error) {
+	return string(s), nil
+}
+
+// newOutboxNotifier posts to a Bot API server that accepts the messages
+// while accept is true and rejects them otherwise.
+func newOutboxNotifier(t *testing.T, store *memoryStore, accept *bool) (*Notifier, *int) {
+	t.Helper()
+
+	var sent int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"id":1,"is_bot":true,"username":"newsbot"}}`)
+		case *accept:
+			sent++
+			_, _ = io.WriteString(w, `{"ok":true,"result":{"message_id":2,"chat":{"id":-100}}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", srv.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatalf("NewBotAPIWithAPIEndpoint() error = %v", err)
+	}
+
+	return NewNotifier(store, store, fixedSummarizer("A summary."), bot, time.Minute, 20*time.Minute, -100, 3, time.Minute), &sent
+}
+
+func TestRetriesOutlastPublishWindow(t *testing.T) {
+	store := newMemoryStore(model.Article{
+		ID:          1,
+		Title:       "Go 1.25 is released",
+		Link:        "https://go.dev/blog/go1.25",
+		Summary:     "The Go team released Go 1.25.",
+		PublishedAt: time.Now().Add(-5 * time.Minute),
+	})
+	accept := false
+	n, _ := newOutboxNotifier(t, store, &accept)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if err := n.SelectAndSendArticle(context.Background()); err != nil {
+			t.Fatalf("attempt %d: SelectAndSendArticle() error = %v", attempt, err)
+		}
+		if got := store.rows[1].article.Attempts; got != attempt {
+			t.Fatalf("attempts after attempt %d = %d, the article was not picked up again", attempt, got)
+		}
+
+		// The retry delay doubles, the article is out of the publish window when it is due again.
+		store.age(1, time.Hour)
+	}
+
+	if state := store.rows[1].article.State; state != model.ArticleStateFailed {
+		t.Fatalf("state after the last attempt = %s, want %s", state, model.ArticleStateFailed)
+	}
+}
+
+func TestRetryFailedArticle(t *testing.T) {
+	store := newMemoryStore(model.Article{
+		ID:          1,
+		Title:       "Go 1.25 is released",
+		Link:        "https://go.dev/blog/go1.25",
+		Summary:     "The Go team released Go 1.25.",
+		PublishedAt: time.Now().Add(-5 * time.Minute),
+	})
+	accept := false
+	n, sent := newOutboxNotifier(t, store, &accept)
+
+	for range 3 {
+		if err := n.SelectAndSendArticle(context.Background()); err != nil {
+			t.Fatalf("SelectAndSendArticle() error = %v", err)
+		}
+		store.age(1, time.Hour)
+	}
+	if state := store.rows[1].article.State; state != model.ArticleStateFailed {
+		t.Fatalf("state = %s, want %s", state, model.ArticleStateFailed)
+	}
+
+	// The admin retries the article days after it was published, once the channel works again.
+	store.age(1, 72*time.Hour)
+	accept = true
+
+	ok, err := store.RetryFailedArticle(context.Background(), 1)
+	if err != nil || !ok {
+		t.Fatalf("RetryFailedArticle() = %v, %v, want true", ok, err)
+	}
+
+	if err := n.SelectAndSendArticle(context.Background()); err != nil {
+		t.Fatalf("SelectAndSendArticle() error = %v", err)
+	}
+
+	if state := store.rows[1].article.State; state != model.ArticleStateSent || *sent != 1 {
+		t.Fatalf("state after the retry = %s with %d messages sent, want %s with 1", state, *sent, model.ArticleStateSent)
+	}
+}
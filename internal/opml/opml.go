@@ -0,0 +1,283 @@
+package opml
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+	feedsource "github.com/ozaitsev92/gonewsbot/internal/source"
+	"golang.org/x/net/html/charset"
+)
+
+type document struct {
+	XMLName xml.Name  `xml:"opml"`
+	Version string    `xml:"version,attr"`
+	Head    head      `xml:"head"`
+	Body    []outline `xml:"body>outline"`
+}
+
+type head struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+// outline carries the standard OPML subscription attributes plus
+// priority, sourceType, options, languages and fetchInterval, which are
+// specific to gonewsbot. The options are the JSON settings of the source type.
+type outline struct {
+	Text          string    `xml:"text,attr"`
+	Title         string    `xml:"title,attr,omitempty"`
+	Type          string    `xml:"type,attr,omitempty"`
+	XMLURL        string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL       string    `xml:"htmlUrl,attr,omitempty"`
+	Category      string    `xml:"category,attr,omitempty"`
+	Priority      string    `xml:"priority,attr,omitempty"`
+	SourceType    string    `xml:"sourceType,attr,omitempty"`
+	Options       string    `xml:"options,attr,omitempty"`
+	Languages     string    `xml:"languages,attr,omitempty"`
+	FetchInterval string    `xml:"fetchInterval,attr,omitempty"`
+	Outlines      []outline `xml:"outline"`
+}
+
+// Parse reads the feed subscriptions of an OPML document. Nested outlines
+// without a feed URL are treated as folders and become the category of the
+// feeds inside them unless a feed has its own category attribute.
+func Parse(r io.Reader) ([]model.Source, error) {
+	var doc document
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid opml document: %w", err)
+	}
+
+	var (
+		sources []model.Source
+		errs    []error
+	)
+	var walk func(outlines []outline, folder string)
+	walk = func(outlines []outline, folder string) {
+		for _, o := range outlines {
+			if o.XMLURL == "" {
+				name := o.Text
+				if name == "" {
+					name = o.Title
+				}
+				walk(o.Outlines, name)
+				continue
+			}
+
+			source := model.Source{
+				Name:     o.Text,
+				FeedURL:  strings.TrimSpace(o.XMLURL),
+				Type:     o.SourceType,
+				Category: firstCategory(o.Category),
+			}
+			if source.Name == "" {
+				source.Name = o.Title
+			}
+			if source.Name == "" {
+				source.Name = source.FeedURL
+			}
+			if source.Category == "" {
+				source.Category = folder
+			}
+			if source.Type == "" {
+				source.Type = model.SourceTypeRSS
+			}
+			source.Priority, _ = strconv.Atoi(o.Priority)
+
+			if o.Options != "" {
+				if !json.Valid([]byte(o.Options)) {
+					errs = append(errs, fmt.Errorf("%s: invalid options", source.FeedURL))
+					continue
+				}
+				source.Options = json.RawMessage(o.Options)
+			}
+			for _, language := range strings.Split(o.Languages, ",") {
+				if language = strings.TrimSpace(language); language != "" {
+					source.Languages = append(source.Languages, strings.ToLower(language))
+				}
+			}
+			if o.FetchInterval != "" {
+				interval, err := time.ParseDuration(o.FetchInterval)
+				if err != nil || interval < 0 {
+					errs = append(errs, fmt.Errorf("%s: invalid fetch interval %q", source.FeedURL, o.FetchInterval))
+					continue
+				}
+				source.FetchInterval = interval
+			}
+
+			sources = append(sources, source)
+		}
+	}
+	walk(doc.Body, "")
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid opml document: %w", errors.Join(errs...))
+	}
+
+	return sources, nil
+}
+
+// firstCategory returns the first entry of a comma separated OPML category
+// list without the leading slash of the category path.
+func firstCategory(category string) string {
+	first, _, _ := strings.Cut(category, ",")
+	return strings.Trim(strings.TrimSpace(first), "/")
+}
+
+// Write renders the sources as an OPML 2.0 document grouped by category.
+// Push sources and the credentials in the options are left out.
+func Write(w io.Writer, title string, sources []model.Source) error {
+	doc := document{
+		Version: "2.0",
+		Head: head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	folders := make(map[string]int)
+	for _, source := range sources {
+		// Push sources have no feed, they are created again by the first submitted article.
+		if source.Type == model.SourceTypePush {
+			continue
+		}
+
+		o := outline{
+			Text:       source.Name,
+			Title:      source.Name,
+			Type:       "rss",
+			XMLURL:     source.FeedURL,
+			Category:   source.Category,
+			Priority:   strconv.Itoa(source.Priority),
+			SourceType: source.Type,
+			Languages:  strings.Join(source.Languages, ","),
+		}
+		o.Options = exportedOptions(source.Options)
+		if source.FetchInterval > 0 {
+			o.FetchInterval = source.FetchInterval.String()
+		}
+
+		if source.Category == "" {
+			doc.Body = append(doc.Body, o)
+			continue
+		}
+
+		i, ok := folders[source.Category]
+		if !ok {
+			i = len(doc.Body)
+			folders[source.Category] = i
+			doc.Body = append(doc.Body, outline{Text: source.Category, Title: source.Category})
+		}
+		doc.Body[i].Outlines = append(doc.Body[i].Outlines, o)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// secretOptionKeys mark the options that are credentials, e.g. the password of
+// an IMAP source or the token of a Mastodon one.
+var secretOptionKeys = []string{"password", "token", "secret"}
+
+// exportedOptions returns the options without the credentials, an exported
+// document is shared as a file and must not leak them. The credentials are
+// set again after the import.
+func exportedOptions(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var options map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &options); err != nil {
+		return ""
+	}
+
+	for key := range options {
+		lower := strings.ToLower(key)
+		for _, secret := range secretOptionKeys {
+			if strings.Contains(lower, secret) {
+				delete(options, key)
+				break
+			}
+		}
+	}
+	if len(options) == 0 {
+		return ""
+	}
+
+	data, err := json.Marshal(options)
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+type SourceStorage interface {
+	GetSources(ctx context.Context) ([]model.Source, error)
+	AddSource(ctx context.Context, source model.Source) (int64, error)
+}
+
+type ImportResult struct {
+	Added   int
+	Skipped int
+	Failed  int
+	Errors  []error
+}
+
+// Import adds the sources that are not stored yet. Sources are matched by feed URL.
+// Failures of single sources are collected in the result instead of aborting the import.
+func Import(ctx context.Context, storage SourceStorage, sources []model.Source) (ImportResult, error) {
+	existing, err := storage.GetSources(ctx)
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	known := make(map[string]struct{}, len(existing))
+	for _, source := range existing {
+		known[source.FeedURL] = struct{}{}
+	}
+
+	var result ImportResult
+	for _, source := range sources {
+		if _, ok := known[source.FeedURL]; ok {
+			result.Skipped++
+			continue
+		}
+
+		if !feedsource.IsKnownType(source.Type) {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Errorf("%s: unknown source type %q", source.FeedURL, source.Type))
+			continue
+		}
+
+		if _, err := storage.AddSource(ctx, source); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %w", source.FeedURL, err))
+			continue
+		}
+
+		known[source.FeedURL] = struct{}{}
+		result.Added++
+	}
+
+	return result, nil
+}
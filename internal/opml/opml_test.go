@@ -0,0 +1,169 @@
+package opml
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+func TestWriteParseRoundTrip(t *testing.T) {
+	sources := []model.Source{
+		{
+			Name:     "Example",
+			FeedURL:  "https://example.com/feed",
+			Type:     model.SourceTypeRSS,
+			Category: "news",
+			Priority: 2,
+		},
+		{
+			Name:          "Scraped",
+			FeedURL:       "https://example.com/blog",
+			Type:          model.SourceTypeHTML,
+			Priority:      1,
+			Options:       json.RawMessage(`{"item":"article","title":"h2 a"}`),
+			Languages:     []string{"en", "de"},
+			FetchInterval: 30 * time.Minute,
+		},
+		{
+			Name:    "HN",
+			FeedURL: "https://hacker-news.firebaseio.com/v0/topstories.json",
+			Type:    model.SourceTypeHackerNews,
+			Options: json.RawMessage(`{"min_score":100}`),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "test", sources); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(parsed) != len(sources) {
+		t.Fatalf("Parse() returned %d sources, want %d", len(parsed), len(sources))
+	}
+
+	byURL := make(map[string]model.Source, len(parsed))
+	for _, source := range parsed {
+		byURL[source.FeedURL] = source
+	}
+
+	for _, want := range sources {
+		got, ok := byURL[want.FeedURL]
+		if !ok {
+			t.Fatalf("source %s is missing after the round trip", want.FeedURL)
+		}
+		if got.Name != want.Name || got.Type != want.Type || got.Category != want.Category ||
+			got.Priority != want.Priority || got.FetchInterval != want.FetchInterval ||
+			string(got.Options) != string(want.Options) || !reflect.DeepEqual(got.Languages, want.Languages) {
+			t.Errorf("source after the round trip = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestWriteLeavesOutSecrets(t *testing.T) {
+	sources := []model.Source{
+		{
+			Name:    "Newsletters",
+			FeedURL: "imaps://news@mail.example.com/INBOX",
+			Type:    model.SourceTypeIMAP,
+			Options: json.RawMessage(`{"password":"hunter2","processed":"move","move_to":"Archive"}`),
+		},
+		{
+			Name:    "Mastodon",
+			FeedURL: "https://mastodon.social/@golang",
+			Type:    model.SourceTypeMastodon,
+			Options: json.RawMessage(`{"access_token":"abc123"}`),
+		},
+		{
+			Name:    "ci",
+			FeedURL: model.PushSourceURL("ci"),
+			Type:    model.SourceTypePush,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "test", sources); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	doc := buf.String()
+	for _, secret := range []string{"hunter2", "abc123"} {
+		if strings.Contains(doc, secret) {
+			t.Errorf("the document contains the secret %q:\n%s", secret, doc)
+		}
+	}
+
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(parsed) != 2 {
+		t.Fatalf("Parse() returned %d sources, want the two that are not push sources", len(parsed))
+	}
+	if got := string(parsed[0].Options); got != `{"move_to":"Archive","processed":"move"}` {
+		t.Errorf("IMAP options = %s, want the settings without the password", got)
+	}
+	if len(parsed[1].Options) != 0 {
+		t.Errorf("Mastodon options = %s, want none", parsed[1].Options)
+	}
+}
+
+func TestParseLegacyCharset(t *testing.T) {
+	doc := "<?xml version=\"1.0\" encoding=\"windows-1251\"?>\n" +
+		"<opml version=\"2.0\"><head><title>feeds</title></head><body>" +
+		"<outline text=\"\xcd\xee\xe2\xee\xf1\xf2\xe8\" xmlUrl=\"https://example.com/feed\"/>" +
+		"</body></opml>"
+
+	sources, err := Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(sources) != 1 || sources[0].Name != "Новости" {
+		t.Fatalf("Parse() = %+v, want the source named Новости", sources)
+	}
+}
+
+type memoryStorage struct {
+	sources []model.Source
+}
+
+func (s *memoryStorage) GetSources(_ context.Context) ([]model.Source, error) {
+	return s.sources, nil
+}
+
+func (s *memoryStorage) AddSource(_ context.Context, source model.Source) (int64, error) {
+	s.sources = append(s.sources, source)
+	return int64(len(s.sources)), nil
+}
+
+func TestImportRejectsUnknownTypes(t *testing.T) {
+	storage := &memoryStorage{sources: []model.Source{{FeedURL: "https://example.com/known"}}}
+
+	result, err := Import(context.Background(), storage, []model.Source{
+		{FeedURL: "https://example.com/known", Type: model.SourceTypeRSS},
+		{FeedURL: "https://example.com/new", Type: model.SourceTypeAtom},
+		{FeedURL: "https://example.com/odd", Type: "gopher"},
+	})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if result.Added != 1 || result.Skipped != 1 || result.Failed != 1 {
+		t.Fatalf("Import() = %+v, want 1 added, 1 skipped and 1 failed", result)
+	}
+	if len(storage.sources) != 2 {
+		t.Fatalf("storage has %d sources, want 2", len(storage.sources))
+	}
+}
@@ -0,0 +1,178 @@
+package ranker
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+type ArticleStorage interface {
+	AllNotPosted(ctx context.Context, since time.Time, limit uint64) ([]model.Article, error)
+	MarkPosted(ctx context.Context, id int64) error
+	PostedCountBySource(ctx context.Context, since time.Time) (map[int64]int, error)
+}
+
+// Ranker scores articles by source priority, freshness and a fairness penalty
+// that grows with the number of articles a source already had posted or queued.
+type Ranker struct {
+	priorityWeight    float64
+	freshnessHalfLife time.Duration
+	fairnessWeight    float64
+	now               func() time.Time
+}
+
+func New(priorityWeight float64, freshnessHalfLife time.Duration, fairnessWeight float64) *Ranker {
+	return &Ranker{
+		priorityWeight:    priorityWeight,
+		freshnessHalfLife: freshnessHalfLife,
+		fairnessWeight:    fairnessWeight,
+		now:               time.Now,
+	}
+}
+
+// WithClock returns a copy of the ranker that uses the given clock instead of time.Now.
+func (r *Ranker) WithClock(now func() time.Time) *Ranker {
+	clone := *r
+	clone.now = now
+	return &clone
+}
+
+// Rank returns the articles ordered from the best to the worst candidate.
+// postedBySource holds the number of recently posted articles per source.
+func (r *Ranker) Rank(articles []model.Article, postedBySource map[int64]int) []model.Article {
+	now := r.now()
+
+	// Newer articles of the same source are seen first, so that the fairness
+	// penalty hits the older backlog of a chatty feed rather than its latest news.
+	ordered := make([]model.Article, len(articles))
+	copy(ordered, articles)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].PublishedAt.After(ordered[j].PublishedAt)
+	})
+
+	seen := make(map[int64]int, len(ordered))
+	scores := make(map[int64]float64, len(ordered))
+	for _, article := range ordered {
+		load := postedBySource[article.SourceID] + seen[article.SourceID]
+		seen[article.SourceID]++
+
+		scores[article.ID] = r.priorityWeight*float64(article.SourcePriority) +
+			r.freshness(now, article.PublishedAt) -
+			r.fairnessWeight*float64(load)
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return scores[ordered[i].ID] > scores[ordered[j].ID]
+	})
+
+	return ordered
+}
+
+// freshness decays from 1 for a just published article by half every freshnessHalfLife.
+func (r *Ranker) freshness(now time.Time, publishedAt time.Time) float64 {
+	if r.freshnessHalfLife <= 0 {
+		return 0
+	}
+
+	age := now.Sub(publishedAt)
+	if age < 0 {
+		age = 0
+	}
+
+	return math.Exp2(-float64(age) / float64(r.freshnessHalfLife))
+}
+
+// Provider is a drop-in notifier.ArticlesProvider that ranks a wider set of
+// candidates before handing the top ones to the notifier.
+type Provider struct {
+	storage        ArticleStorage
+	ranker         *Ranker
+	candidates     uint64
+	fairnessWindow time.Duration
+}
+
+func NewProvider(storage ArticleStorage, ranker *Ranker, candidates uint64, fairnessWindow time.Duration) *Provider {
+	return &Provider{
+		storage:        storage,
+		ranker:         ranker,
+		candidates:     candidates,
+		fairnessWindow: fairnessWindow,
+	}
+}
+
+func (p *Provider) AllNotPosted(ctx context.Context, since time.Time, limit uint64) ([]model.Article, error) {
+	articles, err := p.storage.AllNotPosted(ctx, since, max(limit, p.candidates))
+	if err != nil {
+		return nil, err
+	}
+
+	posted, err := p.storage.PostedCountBySource(ctx, p.ranker.now().Add(-p.fairnessWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := p.ranker.Rank(collapseClusters(articles), posted)
+	if uint64(len(ranked)) > limit {
+		ranked = ranked[:limit]
+	}
+
+	return ranked, nil
+}
+
+// collapseClusters keeps one article per story cluster, the one of the source
+// with the highest priority and the newest one among equals. The others are
+// attached to it as AlsoCoveredBy.
+func collapseClusters(articles []model.Article) []model.Article {
+	best := make(map[int64]int)
+	for i, article := range articles {
+		if article.ClusterID == 0 {
+			continue
+		}
+
+		j, ok := best[article.ClusterID]
+		if !ok || preferred(article, articles[j]) {
+			best[article.ClusterID] = i
+		}
+	}
+
+	coverage := make(map[int64][]model.Coverage)
+	for i, article := range articles {
+		if article.ClusterID != 0 && best[article.ClusterID] != i {
+			link := article.CanonicalLink
+			if link == "" {
+				link = article.Link
+			}
+			coverage[article.ClusterID] = append(coverage[article.ClusterID], model.Coverage{
+				SourceName: article.SourceName,
+				Link:       link,
+			})
+		}
+	}
+
+	collapsed := make([]model.Article, 0, len(articles))
+	for i, article := range articles {
+		if article.ClusterID != 0 {
+			if best[article.ClusterID] != i {
+				continue
+			}
+			article.AlsoCoveredBy = coverage[article.ClusterID]
+		}
+		collapsed = append(collapsed, article)
+	}
+
+	return collapsed
+}
+
+func preferred(a model.Article, b model.Article) bool {
+	if a.SourcePriority != b.SourcePriority {
+		return a.SourcePriority > b.SourcePriority
+	}
+	return a.PublishedAt.After(b.PublishedAt)
+}
+
+func (p *Provider) MarkPosted(ctx context.Context, id int64) error {
+	return p.storage.MarkPosted(ctx, id)
+}
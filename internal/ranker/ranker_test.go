@@ -0,0 +1,123 @@
+package ranker
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+var testNow = time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func fixedClock() time.Time {
+	return testNow
+}
+
+func TestRank(t *testing.T) {
+	tests := []struct {
+		name     string
+		ranker   *Ranker
+		articles []model.Article
+		posted   map[int64]int
+		want     []int64
+	}{
+		{
+			name:   "priority wins over equal freshness",
+			ranker: New(0.1, 6*time.Hour, 0),
+			articles: []model.Article{
+				{ID: 1, SourceID: 1, SourcePriority: 0, PublishedAt: testNow.Add(-time.Hour)},
+				{ID: 2, SourceID: 2, SourcePriority: 5, PublishedAt: testNow.Add(-time.Hour)},
+				{ID: 3, SourceID: 3, SourcePriority: 2, PublishedAt: testNow.Add(-time.Hour)},
+			},
+			want: []int64{2, 3, 1},
+		},
+		{
+			name:   "fresher article wins without priorities",
+			ranker: New(0.1, 6*time.Hour, 0),
+			articles: []model.Article{
+				{ID: 1, SourceID: 1, PublishedAt: testNow.Add(-12 * time.Hour)},
+				{ID: 2, SourceID: 2, PublishedAt: testNow.Add(-time.Minute)},
+				{ID: 3, SourceID: 3, PublishedAt: testNow.Add(-6 * time.Hour)},
+			},
+			want: []int64{2, 3, 1},
+		},
+		{
+			name:   "freshness outweighs a small priority gap",
+			ranker: New(0.1, 6*time.Hour, 0),
+			articles: []model.Article{
+				{ID: 1, SourceID: 1, SourcePriority: 1, PublishedAt: testNow.Add(-24 * time.Hour)},
+				{ID: 2, SourceID: 2, SourcePriority: 0, PublishedAt: testNow},
+			},
+			want: []int64{2, 1},
+		},
+		{
+			name:   "fairness pushes back the backlog of a chatty source",
+			ranker: New(0.1, 6*time.Hour, 0.2),
+			articles: []model.Article{
+				{ID: 1, SourceID: 1, PublishedAt: testNow.Add(-time.Minute)},
+				{ID: 2, SourceID: 1, PublishedAt: testNow.Add(-2 * time.Minute)},
+				{ID: 3, SourceID: 1, PublishedAt: testNow.Add(-3 * time.Minute)},
+				{ID: 4, SourceID: 2, PublishedAt: testNow.Add(-30 * time.Minute)},
+			},
+			want: []int64{1, 4, 2, 3},
+		},
+		{
+			name:   "fairness counts the recently posted articles",
+			ranker: New(0.1, 6*time.Hour, 0.2),
+			articles: []model.Article{
+				{ID: 1, SourceID: 1, PublishedAt: testNow.Add(-time.Minute)},
+				{ID: 2, SourceID: 2, PublishedAt: testNow.Add(-time.Hour)},
+			},
+			posted: map[int64]int{1: 3},
+			want:   []int64{2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranked := tt.ranker.WithClock(fixedClock).Rank(tt.articles, tt.posted)
+
+			got := make([]int64, len(ranked))
+			for i, article := range ranked {
+				got[i] = article.ID
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Rank() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Rank() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFreshnessHalfLife(t *testing.T) {
+	r := New(0, 6*time.Hour, 0).WithClock(fixedClock)
+
+	tests := []struct {
+		name        string
+		publishedAt time.Time
+		want        float64
+	}{
+		{name: "just published", publishedAt: testNow, want: 1},
+		{name: "one half-life", publishedAt: testNow.Add(-6 * time.Hour), want: 0.5},
+		{name: "two half-lives", publishedAt: testNow.Add(-12 * time.Hour), want: 0.25},
+		{name: "published in the future", publishedAt: testNow.Add(time.Hour), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.freshness(r.now(), tt.publishedAt); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("freshness() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := New(0, 0, 0).freshness(testNow, testNow); got != 0 {
+		t.Errorf("freshness() without a half-life = %v, want 0", got)
+	}
+}
@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+	"golang.org/x/time/rate"
+)
+
+// HostLimiter keeps a token bucket per site. A site is the registrable domain
+// of the host, so that the publications of one platform (alice.substack.com,
+// bob.substack.com) share a bucket.
+type HostLimiter struct {
+	interval time.Duration
+	burst    int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewHostLimiter allows burst requests at once to a site and one more every
+// interval after that. A zero interval disables the limiting.
+func NewHostLimiter(interval time.Duration, burst int) *HostLimiter {
+	return &HostLimiter{
+		interval: interval,
+		burst:    max(burst, 1),
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// Wait blocks until the site of the host may be requested or the context is done.
+func (l *HostLimiter) Wait(ctx context.Context, host string) error {
+	if l.interval <= 0 {
+		return nil
+	}
+
+	return l.limiter(site(host)).Wait(ctx)
+}
+
+// The buckets are never dropped, there is one per site the bot follows.
+func (l *HostLimiter) limiter(site string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter, ok := l.limiters[site]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Every(l.interval), l.burst)
+		l.limiters[site] = limiter
+	}
+
+	return limiter
+}
+
+// Transport waits for the bucket of the host before every request of the base
+// transport. The timeout limits a request from the moment the bucket lets it
+// through, like the Timeout of http.Client, which would count the wait as well.
+// A zero timeout leaves the request to its context.
+func (l *HostLimiter) Transport(base http.RoundTripper, timeout time.Duration) http.RoundTripper {
+	return &transport{base: base, limiter: l, timeout: timeout}
+}
+
+type transport struct {
+	base    http.RoundTripper
+	limiter *HostLimiter
+	timeout time.Duration
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The timeout covers reading the body too, it ends when the body is closed.
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+func site(host string) string {
+	host = strings.ToLower(host)
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		// IP addresses and bare public suffixes are their own site.
+		return host
+	}
+
+	return domain
+}
@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransportTimeoutExcludesWait(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+
+	// Every request after the first waits longer for the bucket than the timeout allows.
+	limiter := NewHostLimiter(100*time.Millisecond, 1)
+	client := &http.Client{Transport: limiter.Transport(http.DefaultTransport, 50*time.Millisecond)}
+
+	for i := range 3 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("request %d error = %v", i, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil || string(body) != "ok" {
+			t.Fatalf("request %d body = %q, %v", i, body, err)
+		}
+	}
+}
+
+func TestTransportTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: NewHostLimiter(0, 1).Transport(http.DefaultTransport, 50*time.Millisecond)}
+
+	_, err := client.Get(srv.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("request error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,176 @@
+package source
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      atomText       `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    atomText       `xml:"summary"`
+	Content    atomText       `xml:"content"`
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomText struct {
+	Type     string `xml:"type,attr"`
+	Body     string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+// String returns the text content; xhtml constructs keep their markup.
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.InnerXML)
+	}
+	return strings.TrimSpace(t.Body)
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type AtomSource struct {
+	httpFeed
+
+	URL        string
+	SourceID   int64
+	SourceName string
+}
+
+func NewAtomSourceFromModel(m model.Source, client *http.Client) AtomSource {
+	return AtomSource{
+		httpFeed:   newHTTPFeed(client, Validators{ETag: m.ETag, LastModified: m.LastModified}),
+		URL:        m.FeedURL,
+		SourceID:   m.ID,
+		SourceName: m.Name,
+	}
+}
+
+func (s AtomSource) Fetch(ctx context.Context) ([]model.Item, error) {
+	doc, err := s.fetch(ctx, s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	title, items, err := parseAtom(doc.body)
+	if err != nil {
+		return nil, err
+	}
+	*s.title = title
+
+	for i := range items {
+		items[i].SourceName = s.SourceName
+	}
+
+	return items, nil
+}
+
+func (s AtomSource) ID() int64 {
+	return s.SourceID
+}
+
+func (s AtomSource) Name() string {
+	return s.SourceName
+}
+
+// ParseAtom parses an Atom 1.0 document into items.
+func ParseAtom(data []byte) ([]model.Item, error) {
+	_, items, err := parseAtom(data)
+	return items, err
+}
+
+func parseAtom(data []byte) (string, []model.Item, error) {
+	var feed atomFeed
+	if err := newXMLDecoder(data).Decode(&feed); err != nil {
+		return "", nil, err
+	}
+
+	items := make([]model.Item, len(feed.Entries))
+	for i, entry := range feed.Entries {
+		item := model.Item{
+			Title:   entry.Title.String(),
+			Summary: entry.Summary.String(),
+			Content: entry.Content.String(),
+			Date:    parseRFC3339(entry.Published, entry.Updated),
+		}
+
+		for _, link := range entry.Links {
+			switch link.Rel {
+			case "", "alternate":
+				if item.Link == "" {
+					item.Link = link.Href
+				}
+			case "enclosure":
+				length, _ := strconv.ParseInt(link.Length, 10, 64)
+				item.Enclosures = append(item.Enclosures, model.Enclosure{
+					URL:    link.Href,
+					Type:   link.Type,
+					Length: length,
+				})
+			}
+		}
+		if item.Link == "" && strings.HasPrefix(entry.ID, "http") {
+			item.Link = entry.ID
+		}
+
+		names := make([]string, 0, len(entry.Authors))
+		for _, author := range entry.Authors {
+			if author.Name != "" {
+				names = append(names, author.Name)
+			}
+		}
+		item.Author = strings.Join(names, ", ")
+
+		for _, category := range entry.Categories {
+			if category.Term != "" {
+				item.Categories = append(item.Categories, category.Term)
+			} else if category.Label != "" {
+				item.Categories = append(item.Categories, category.Label)
+			}
+		}
+
+		items[i] = item
+	}
+
+	return strings.TrimSpace(feed.Title), items, nil
+}
+
+// parseRFC3339 returns the first of the values that is a valid RFC 3339 timestamp.
+func parseRFC3339(values ...string) time.Time {
+	for _, value := range values {
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(value)); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,94 @@
+package source
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+var ErrUnknownFeedType = errors.New("unable to detect feed type")
+
+// IsKnownType reports whether sourceType names a supported source type.
+func IsKnownType(sourceType string) bool {
+	switch sourceType {
+	case model.SourceTypeRSS, model.SourceTypeAtom, model.SourceTypeJSONFeed, model.SourceTypeHTML,
+		model.SourceTypeHackerNews, model.SourceTypeReddit, model.SourceTypeGitHub,
+		model.SourceTypePodcast, model.SourceTypeYouTube, model.SourceTypeMastodon,
+		model.SourceTypeIMAP:
+		return true
+	default:
+		return false
+	}
+}
+
+type Detector struct {
+	client *http.Client
+}
+
+func NewDetector(client *http.Client) *Detector {
+	return &Detector{client: client}
+}
+
+// DetectType guesses the source type from the response content type and body.
+// The body wins over the content type, which servers often get wrong.
+func DetectType(contentType string, body []byte) (string, error) {
+	trimmed := bytes.TrimSpace(body)
+
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var probe struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(trimmed, &probe); err == nil && strings.HasPrefix(probe.Version, "https://jsonfeed.org/") {
+			return model.SourceTypeJSONFeed, nil
+		}
+	}
+
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		switch xmlRootElement(trimmed) {
+		case "feed":
+			if bytes.Contains(trimmed, []byte(youTubeNamespace)) {
+				return model.SourceTypeYouTube, nil
+			}
+			return model.SourceTypeAtom, nil
+		case "rss":
+			// Podcasts are RSS feeds whose episodes carry the audio as enclosures.
+			if bytes.Contains(trimmed, []byte(itunesNamespace)) && bytes.Contains(trimmed, []byte("<enclosure")) {
+				return model.SourceTypePodcast, nil
+			}
+			return model.SourceTypeRSS, nil
+		case "RDF":
+			return model.SourceTypeRSS, nil
+		}
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch mediaType {
+	case "application/feed+json":
+		return model.SourceTypeJSONFeed, nil
+	case "application/atom+xml":
+		return model.SourceTypeAtom, nil
+	case "application/rss+xml", "application/rdf+xml":
+		return model.SourceTypeRSS, nil
+	}
+
+	return "", ErrUnknownFeedType
+}
+
+func xmlRootElement(data []byte) string {
+	decoder := newXMLDecoder(data)
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
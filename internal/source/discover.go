@@ -0,0 +1,180 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/SlyMarbo/rss"
+	"github.com/ozaitsev92/gonewsbot/internal/htmlutil"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+	"golang.org/x/net/html"
+)
+
+// commonFeedPaths are tried when the page does not advertise any feed.
+var commonFeedPaths = []string{
+	"/feed",
+	"/rss",
+	"/rss.xml",
+	"/feed.xml",
+	"/atom.xml",
+	"/index.xml",
+	"/feed.json",
+}
+
+var feedMediaTypes = map[string]struct{}{
+	"application/rss+xml":   {},
+	"application/atom+xml":  {},
+	"application/rdf+xml":   {},
+	"application/feed+json": {},
+	"application/json":      {},
+}
+
+var ErrNoFeedsFound = errors.New("no feeds found")
+
+// Candidate is a feed that was found and successfully parsed.
+type Candidate struct {
+	URL       string
+	Type      string
+	ItemCount int
+}
+
+// Discover returns the feeds available at the URL. A URL that already points
+// to a feed yields a single candidate. For HTML pages the advertised
+// <link rel="alternate"> feeds are checked first and the common feed paths
+// of the site after that. Only candidates that parse are returned.
+func (d *Detector) Discover(ctx context.Context, pageURL string) ([]Candidate, error) {
+	doc, err := fetchDocument(ctx, d.client, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if candidate, err := parseCandidate(pageURL, doc); err == nil {
+		return []Candidate{candidate}, nil
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	links := alternateFeedLinks(base, doc.body)
+	if len(links) == 0 {
+		for _, path := range commonFeedPaths {
+			links = append(links, base.ResolveReference(&url.URL{Path: path}).String())
+		}
+	}
+
+	var candidates []Candidate
+	seen := make(map[string]struct{}, len(links))
+	for _, link := range links {
+		if _, ok := seen[link]; ok {
+			continue
+		}
+		seen[link] = struct{}{}
+
+		doc, err := fetchDocument(ctx, d.client, link, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+
+		candidate, err := parseCandidate(link, doc)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w at %s", ErrNoFeedsFound, pageURL)
+	}
+
+	return candidates, nil
+}
+
+func parseCandidate(feedURL string, doc *document) (Candidate, error) {
+	sourceType, err := DetectType(doc.contentType, doc.body)
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	items, err := ParseItems(sourceType, doc.body)
+	if err != nil {
+		return Candidate{}, err
+	}
+
+	return Candidate{
+		URL:       feedURL,
+		Type:      sourceType,
+		ItemCount: len(items),
+	}, nil
+}
+
+// ParseItems parses a feed document of the given source type.
+func ParseItems(sourceType string, data []byte) ([]model.Item, error) {
+	switch sourceType {
+	case model.SourceTypeRSS:
+		feed, err := rss.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		return rssItems(feed, ""), nil
+	case model.SourceTypeAtom:
+		return ParseAtom(data)
+	case model.SourceTypeJSONFeed:
+		return ParseJSONFeed(data)
+	case model.SourceTypePodcast:
+		return ParsePodcast(data)
+	case model.SourceTypeYouTube:
+		return ParseYouTube(data)
+	default:
+		return nil, fmt.Errorf("unknown source type %q", sourceType)
+	}
+}
+
+// alternateFeedLinks returns the absolute URLs of the feeds advertised
+// with <link rel="alternate"> tags of an HTML page.
+func alternateFeedLinks(base *url.URL, body []byte) []string {
+	root, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	var links []string
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.Data == "link" {
+			var rel, mediaType, href string
+			for _, attr := range node.Attr {
+				switch strings.ToLower(attr.Key) {
+				case "rel":
+					rel = strings.ToLower(attr.Val)
+				case "type":
+					mediaType = strings.ToLower(strings.TrimSpace(attr.Val))
+				case "href":
+					href = strings.TrimSpace(attr.Val)
+				}
+			}
+
+			_, isFeed := feedMediaTypes[mediaType]
+			if isFeed && href != "" && htmlutil.HasToken(rel, "alternate") {
+				if ref, err := url.Parse(href); err == nil {
+					links = append(links, base.ResolveReference(ref).String())
+				}
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return links
+}
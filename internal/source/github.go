@@ -0,0 +1,272 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const gitHubAPIURL = "https://api.github.com"
+
+// GitHubOptions configure a github_releases source.
+type GitHubOptions struct {
+	// Token overrides the global GitHub token for this source.
+	Token              string `json:"token"`
+	Tags               bool   `json:"tags"`
+	IncludePrereleases bool   `json:"include_prereleases"`
+	VersionRange       string `json:"version_range"`
+}
+
+type gitHubRelease struct {
+	Name        string    `json:"name"`
+	TagName     string    `json:"tag_name"`
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"html_url"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	Author      struct {
+		Login string `json:"login"`
+	} `json:"author"`
+}
+
+type gitHubTag struct {
+	Name string `json:"name"`
+}
+
+// GitHubReleasesSource follows the releases, or the tags, of a repository
+// through the GitHub REST API. The feed URL is the repository URL on
+// github.com or its API URL, e.g. https://api.github.com/repos/golang/go.
+// In the tags mode the names of the tags seen are kept as the cursor.
+type GitHubReleasesSource struct {
+	httpFeed
+
+	URL          string
+	SourceID     int64
+	SourceName   string
+	repo         string
+	apiURL       string
+	token        string
+	options      GitHubOptions
+	versionRange semverRange
+	cursor       *string
+}
+
+func NewGitHubReleasesSourceFromModel(m model.Source, client *http.Client, token string) (GitHubReleasesSource, error) {
+	var options GitHubOptions
+	if len(m.Options) > 0 {
+		if err := json.Unmarshal(m.Options, &options); err != nil {
+			return GitHubReleasesSource{}, fmt.Errorf("invalid github options: %w", err)
+		}
+	}
+
+	versionRange, err := parseSemverRange(options.VersionRange)
+	if err != nil {
+		return GitHubReleasesSource{}, err
+	}
+
+	repo, apiURL, err := gitHubRepoAPIURL(m.FeedURL)
+	if err != nil {
+		return GitHubReleasesSource{}, err
+	}
+
+	if options.Token != "" {
+		token = options.Token
+	}
+
+	cursor := m.Cursor
+
+	return GitHubReleasesSource{
+		httpFeed:     newHTTPFeed(client, Validators{ETag: m.ETag, LastModified: m.LastModified}),
+		URL:          m.FeedURL,
+		SourceID:     m.ID,
+		SourceName:   m.Name,
+		repo:         repo,
+		apiURL:       apiURL,
+		token:        token,
+		options:      options,
+		versionRange: versionRange,
+		cursor:       &cursor,
+	}, nil
+}
+
+// gitHubRepoAPIURL returns "owner/repo" and the API URL of the repository.
+func gitHubRepoAPIURL(feedURL string) (string, string, error) {
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	path := strings.Trim(u.Path, "/")
+	if u.Host == "github.com" || u.Host == "www.github.com" {
+		parts := strings.Split(path, "/")
+		if len(parts) < 2 {
+			return "", "", fmt.Errorf("invalid github repository url %q", feedURL)
+		}
+		repo := parts[0] + "/" + strings.TrimSuffix(parts[1], ".git")
+		return repo, gitHubAPIURL + "/repos/" + repo, nil
+	}
+
+	// Any other host is an API endpoint, e.g. a GitHub Enterprise server.
+	_, repo, ok := strings.Cut(path, "repos/")
+	if !ok || strings.Count(repo, "/") != 1 {
+		return "", "", fmt.Errorf("invalid github repository api url %q", feedURL)
+	}
+
+	return repo, strings.TrimSuffix(feedURL, "/"), nil
+}
+
+// Cursor returns the names of the tags seen by the last fetch, separated by
+// spaces that tag names cannot contain.
+func (s GitHubReleasesSource) Cursor() string {
+	return *s.cursor
+}
+
+func (s GitHubReleasesSource) Fetch(ctx context.Context) ([]model.Item, error) {
+	*s.title = s.repo
+
+	if s.options.Tags {
+		return s.fetchTags(ctx)
+	}
+
+	return s.fetchReleases(ctx)
+}
+
+func (s GitHubReleasesSource) fetchReleases(ctx context.Context) ([]model.Item, error) {
+	var releases []gitHubRelease
+	if err := s.get(ctx, s.apiURL+"/releases", &releases); err != nil {
+		return nil, err
+	}
+
+	items := make([]model.Item, 0, len(releases))
+	for _, release := range releases {
+		if release.Draft || (release.Prerelease && !s.options.IncludePrereleases) {
+			continue
+		}
+		if !s.matchesVersionRange(release.TagName) {
+			continue
+		}
+
+		name := release.Name
+		if name == "" {
+			name = release.TagName
+		}
+
+		item := model.Item{
+			Title:      fmt.Sprintf("%s %s", s.repo, name),
+			Link:       release.HTMLURL,
+			Date:       release.PublishedAt,
+			Summary:    release.Body,
+			Author:     release.Author.Login,
+			Categories: []string{release.TagName},
+			SourceName: s.SourceName,
+		}
+		if release.Prerelease {
+			item.Categories = append(item.Categories, "prerelease")
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// fetchTags covers repositories that tag versions without publishing releases.
+// Tags carry no date, so the items are dated when they are first seen. The
+// first fetch only records the existing tags, otherwise all of them would be
+// posted as new.
+func (s GitHubReleasesSource) fetchTags(ctx context.Context) ([]model.Item, error) {
+	var tags []gitHubTag
+	if err := s.get(ctx, s.apiURL+"/tags", &tags); err != nil {
+		return nil, err
+	}
+
+	seeded := *s.cursor != ""
+	known := make(map[string]bool)
+	for _, name := range strings.Fields(*s.cursor) {
+		known[name] = true
+	}
+
+	names := make([]string, 0, len(tags))
+	items := make([]model.Item, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+		if !seeded || known[tag.Name] {
+			continue
+		}
+
+		version, err := parseSemver(tag.Name)
+		if err == nil && len(version.prerelease) > 0 && !s.options.IncludePrereleases {
+			continue
+		}
+		if !s.matchesVersionRange(tag.Name) {
+			continue
+		}
+
+		items = append(items, model.Item{
+			Title:      fmt.Sprintf("%s %s", s.repo, tag.Name),
+			Link:       fmt.Sprintf("https://github.com/%s/releases/tag/%s", s.repo, url.PathEscape(tag.Name)),
+			Categories: []string{tag.Name},
+			SourceName: s.SourceName,
+		})
+	}
+
+	if len(names) > 0 {
+		*s.cursor = strings.Join(names, " ")
+	}
+
+	return items, nil
+}
+
+// matchesVersionRange skips tags that are not versions only when a range is configured.
+func (s GitHubReleasesSource) matchesVersionRange(tag string) bool {
+	if len(s.versionRange) == 0 {
+		return true
+	}
+
+	version, err := parseSemver(tag)
+	if err != nil {
+		return false
+	}
+
+	return s.versionRange.contains(version)
+}
+
+func (s GitHubReleasesSource) get(ctx context.Context, apiURL string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("User-Agent", userAgent)
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	doc, err := s.do(req)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(doc.body, target); err != nil {
+		return fmt.Errorf("invalid github api response: %w", err)
+	}
+
+	return nil
+}
+
+func (s GitHubReleasesSource) ID() int64 {
+	return s.SourceID
+}
+
+func (s GitHubReleasesSource) Name() string {
+	return s.SourceName
+}
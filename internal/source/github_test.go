@@ -0,0 +1,215 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// serveGitHub answers the API paths of the golang/example repository with the
+// recorded fixtures and checks the headers of every request.
+func serveGitHub(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+
+	fixtures := map[string]string{
+		"/repos/golang/example/releases": "github_releases.json",
+		"/repos/golang/example/tags":     "github_tags.json",
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept"); got != "application/vnd.github+json" {
+			t.Errorf("Accept = %q", got)
+		}
+
+		wantAuth := ""
+		if token != "" {
+			wantAuth = "Bearer " + token
+		}
+		if got := r.Header.Get("Authorization"); got != wantAuth {
+			t.Errorf("Authorization = %q, want %q", got, wantAuth)
+		}
+
+		name, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Errorf("read fixture: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newTestGitHubSource(t *testing.T, srv *httptest.Server, options string, token string) GitHubReleasesSource {
+	t.Helper()
+
+	return newTestGitHubSourceAt(t, srv, options, token, "")
+}
+
+// newTestGitHubSourceAt creates the source with the cursor saved by an earlier fetch.
+func newTestGitHubSourceAt(t *testing.T, srv *httptest.Server, options string, token string, cursor string) GitHubReleasesSource {
+	t.Helper()
+
+	src, err := NewGitHubReleasesSourceFromModel(model.Source{
+		Name:    "example",
+		FeedURL: srv.URL + "/repos/golang/example",
+		Type:    model.SourceTypeGitHub,
+		Options: json.RawMessage(options),
+		Cursor:  cursor,
+	}, srv.Client(), token)
+	if err != nil {
+		t.Fatalf("NewGitHubReleasesSourceFromModel() error = %v", err)
+	}
+
+	return src
+}
+
+func TestGitHubReleases(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		want    []model.Item
+	}{
+		{
+			name:    "stable releases",
+			options: `{}`,
+			want: []model.Item{
+				{
+					Title:      "golang/example v1.2.0",
+					Link:       "https://github.com/golang/example/releases/tag/v1.2.0",
+					Date:       time.Date(2025, 9, 20, 12, 0, 0, 0, time.UTC),
+					Summary:    "## Changes\n\n- Faster parsing",
+					Author:     "alice",
+					Categories: []string{"v1.2.0"},
+					SourceName: "example",
+				},
+				{
+					Title:      "golang/example Beta",
+					Link:       "https://github.com/golang/example/releases/tag/v0.9.0",
+					Date:       time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC),
+					Summary:    "Beta release.",
+					Author:     "alice",
+					Categories: []string{"v0.9.0"},
+					SourceName: "example",
+				},
+			},
+		},
+		{
+			name:    "prereleases within a range",
+			options: `{"include_prereleases": true, "version_range": ">=1.0.0"}`,
+			want: []model.Item{
+				{
+					Title:      "golang/example v2.0.0 RC 1",
+					Link:       "https://github.com/golang/example/releases/tag/v2.0.0-rc.1",
+					Date:       time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC),
+					Summary:    "First release candidate of v2.",
+					Author:     "gopherbot",
+					Categories: []string{"v2.0.0-rc.1", "prerelease"},
+					SourceName: "example",
+				},
+				{
+					Title:      "golang/example v1.2.0",
+					Link:       "https://github.com/golang/example/releases/tag/v1.2.0",
+					Date:       time.Date(2025, 9, 20, 12, 0, 0, 0, time.UTC),
+					Summary:    "## Changes\n\n- Faster parsing",
+					Author:     "alice",
+					Categories: []string{"v1.2.0"},
+					SourceName: "example",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serveGitHub(t, "secret")
+			src := newTestGitHubSource(t, srv, tt.options, "secret")
+
+			items, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			assertItems(t, items, tt.want)
+		})
+	}
+}
+
+func TestGitHubTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		options string
+		want    []string
+	}{
+		{name: "stable tags", options: `{"tags": true}`, want: []string{"v1.2.0", "nightly"}},
+		{name: "with prereleases", options: `{"tags": true, "include_prereleases": true}`, want: []string{"v1.3.0-beta.1", "v1.2.0", "nightly"}},
+		{name: "within a range", options: `{"tags": true, "version_range": "^1.0.0"}`, want: []string{"v1.2.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := serveGitHub(t, "")
+			// Only v0.9.0 was tagged when the source was added.
+			src := newTestGitHubSourceAt(t, srv, tt.options, "", "v0.9.0")
+
+			items, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			want := make([]model.Item, len(tt.want))
+			for i, tag := range tt.want {
+				want[i] = model.Item{
+					Title:      "golang/example " + tag,
+					Link:       "https://github.com/golang/example/releases/tag/" + tag,
+					Categories: []string{tag},
+					SourceName: "example",
+				}
+			}
+			assertItems(t, items, want)
+		})
+	}
+}
+
+func TestGitHubTagsFirstFetch(t *testing.T) {
+	srv := serveGitHub(t, "")
+	src := newTestGitHubSource(t, srv, `{"tags": true}`, "")
+
+	items, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("Fetch() returned %d items, want the existing tags to be only recorded", len(items))
+	}
+
+	cursor := src.Cursor()
+	if want := "v1.3.0-beta.1 v1.2.0 nightly v0.9.0"; cursor != want {
+		t.Fatalf("Cursor() = %q, want %q", cursor, want)
+	}
+
+	items, err = newTestGitHubSourceAt(t, srv, `{"tags": true}`, "", cursor).Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("the next Fetch() returned %d items, want none until a tag is pushed", len(items))
+	}
+}
@@ -0,0 +1,259 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const hackerNewsItemURL = "https://news.ycombinator.com/item?id="
+
+const (
+	// defaultFirebaseLimit is lower than the limit of the other ranked sources,
+	// the Firebase API costs a request per story and the requests share the
+	// rate limit of the site.
+	defaultFirebaseLimit = 10
+	// firebaseConcurrency is how many stories of the list are fetched at once.
+	firebaseConcurrency = 4
+)
+
+type algoliaResponse struct {
+	Hits []algoliaHit `json:"hits"`
+}
+
+type algoliaHit struct {
+	ObjectID    string   `json:"objectID"`
+	Title       string   `json:"title"`
+	URL         string   `json:"url"`
+	Author      string   `json:"author"`
+	Points      int      `json:"points"`
+	NumComments int      `json:"num_comments"`
+	StoryText   string   `json:"story_text"`
+	CreatedAtI  int64    `json:"created_at_i"`
+	Tags        []string `json:"_tags"`
+}
+
+type firebaseItem struct {
+	ID          int64  `json:"id"`
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	By          string `json:"by"`
+	Score       int    `json:"score"`
+	Descendants int    `json:"descendants"`
+	Text        string `json:"text"`
+	Time        int64  `json:"time"`
+	Dead        bool   `json:"dead"`
+	Deleted     bool   `json:"deleted"`
+}
+
+// HackerNewsSource reads stories from either the Algolia search API, e.g.
+// https://hn.algolia.com/api/v1/search?tags=front_page, or a story list of
+// the Firebase API, e.g. https://hacker-news.firebaseio.com/v0/topstories.json.
+// The API is chosen by the feed URL.
+type HackerNewsSource struct {
+	httpFeed
+
+	URL        string
+	SourceID   int64
+	SourceName string
+	options    ScoreOptions
+}
+
+func NewHackerNewsSourceFromModel(m model.Source, client *http.Client) (HackerNewsSource, error) {
+	defaultLimit := defaultScoreLimit
+	if isFirebaseList(m.FeedURL) {
+		defaultLimit = defaultFirebaseLimit
+	}
+
+	options, err := parseScoreOptions(m.Options, defaultLimit)
+	if err != nil {
+		return HackerNewsSource{}, err
+	}
+
+	return HackerNewsSource{
+		httpFeed:   newHTTPFeed(client, Validators{ETag: m.ETag, LastModified: m.LastModified}),
+		URL:        m.FeedURL,
+		SourceID:   m.ID,
+		SourceName: m.Name,
+		options:    options,
+	}, nil
+}
+
+func (s HackerNewsSource) Fetch(ctx context.Context) ([]model.Item, error) {
+	var (
+		items []model.Item
+		err   error
+	)
+
+	if isFirebaseList(s.URL) {
+		items, err = s.fetchFirebase(ctx)
+	} else {
+		items, err = s.fetchAlgolia(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	*s.title = "Hacker News"
+
+	accepted := items[:0]
+	for _, item := range items {
+		if s.options.accepts(item) {
+			item.SourceName = s.SourceName
+			accepted = append(accepted, item)
+		}
+	}
+
+	return accepted, nil
+}
+
+func (s HackerNewsSource) fetchAlgolia(ctx context.Context) ([]model.Item, error) {
+	doc, err := s.fetch(ctx, s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp algoliaResponse
+	if err := json.Unmarshal(doc.body, &resp); err != nil {
+		return nil, err
+	}
+
+	hits := resp.Hits[:min(len(resp.Hits), s.options.Limit)]
+	items := make([]model.Item, len(hits))
+	for i, hit := range hits {
+		link := hit.URL
+		if link == "" {
+			link = hackerNewsItemURL + hit.ObjectID
+		}
+
+		items[i] = model.Item{
+			Title:    hit.Title,
+			Link:     link,
+			Date:     time.Unix(hit.CreatedAtI, 0).UTC(),
+			Summary:  hit.StoryText,
+			Author:   hit.Author,
+			Score:    hit.Points,
+			Comments: hit.NumComments,
+		}
+	}
+
+	return items, nil
+}
+
+func (s HackerNewsSource) fetchFirebase(ctx context.Context) ([]model.Item, error) {
+	doc, err := s.fetch(ctx, s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	if err := json.Unmarshal(doc.body, &ids); err != nil {
+		return nil, err
+	}
+
+	listURL, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	ids = ids[:min(len(ids), s.options.Limit)]
+	stories := make([]firebaseItem, len(ids))
+	errs := make([]error, len(ids))
+
+	// The stories are fetched by a few workers, the list keeps its order.
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(firebaseConcurrency, len(ids)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				stories[i], errs[i] = s.fetchFirebaseItem(ctx, listURL, ids[i])
+			}
+		}()
+	}
+	for i := range ids {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	items := make([]model.Item, 0, len(ids))
+	var lastErr error
+	failed := 0
+	for i, story := range stories {
+		if err := errs[i]; err != nil {
+			// One broken item does not cost the source the rest of the list.
+			slog.Warn("skipped hacker news item", "source", s.SourceName, "item_id", ids[i], "error", err)
+			lastErr = err
+			failed++
+			continue
+		}
+
+		if story.Dead || story.Deleted || story.Type != "story" {
+			continue
+		}
+
+		link := story.URL
+		if link == "" {
+			link = hackerNewsItemURL + strconv.FormatInt(story.ID, 10)
+		}
+
+		items = append(items, model.Item{
+			Title:    story.Title,
+			Link:     link,
+			Date:     time.Unix(story.Time, 0).UTC(),
+			Summary:  story.Text,
+			Author:   story.By,
+			Score:    story.Score,
+			Comments: story.Descendants,
+		})
+	}
+
+	if failed > 0 && failed == len(ids) {
+		return nil, fmt.Errorf("failed to fetch all %d hacker news items: %w", failed, lastErr)
+	}
+
+	return items, nil
+}
+
+func (s HackerNewsSource) fetchFirebaseItem(ctx context.Context, listURL *url.URL, id int64) (firebaseItem, error) {
+	itemURL := listURL.ResolveReference(&url.URL{Path: "item/" + strconv.FormatInt(id, 10) + ".json"})
+
+	itemDoc, err := fetchDocument(ctx, s.client, itemURL.String(), nil)
+	if err != nil {
+		return firebaseItem{}, err
+	}
+
+	var story firebaseItem
+	if err := json.Unmarshal(itemDoc.body, &story); err != nil {
+		return firebaseItem{}, fmt.Errorf("invalid item: %w", err)
+	}
+
+	return story, nil
+}
+
+// isFirebaseList tells the story lists of the Firebase API from the Algolia search.
+func isFirebaseList(feedURL string) bool {
+	return strings.HasSuffix(feedURL, "stories.json")
+}
+
+func (s HackerNewsSource) ID() int64 {
+	return s.SourceID
+}
+
+func (s HackerNewsSource) Name() string {
+	return s.SourceName
+}
@@ -0,0 +1,184 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// serveFixtures answers every path of the map with the content of the testdata file.
+func serveFixtures(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Errorf("read fixture: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestHackerNewsAlgolia(t *testing.T) {
+	srv := serveFixtures(t, map[string]string{"/api/v1/search": "hackernews_algolia.json"})
+
+	src, err := NewHackerNewsSourceFromModel(model.Source{
+		Name:    "HN",
+		FeedURL: srv.URL + "/api/v1/search?tags=front_page",
+		Options: json.RawMessage(`{"min_score": 100}`),
+	}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewHackerNewsSourceFromModel() error = %v", err)
+	}
+
+	items, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	want := []model.Item{
+		{
+			Title:      "Show HN: A tiny Go news bot",
+			Link:       "https://example.com/gonewsbot",
+			Date:       time.Unix(1759320000, 0).UTC(),
+			Author:     "alice",
+			Score:      250,
+			Comments:   80,
+			SourceName: "HN",
+		},
+		{
+			Title:      "Ask HN: What are you reading?",
+			Link:       hackerNewsItemURL + "41000002",
+			Date:       time.Unix(1759316400, 0).UTC(),
+			Summary:    "Books, papers, anything.",
+			Author:     "bob",
+			Score:      120,
+			Comments:   300,
+			SourceName: "HN",
+		},
+	}
+	assertItems(t, items, want)
+}
+
+func TestHackerNewsFirebase(t *testing.T) {
+	// Item 102 is missing on the server, it is skipped rather than failing the source.
+	srv := serveFixtures(t, map[string]string{
+		"/v0/topstories.json": "hackernews_topstories.json",
+		"/v0/item/101.json":   "hackernews_item_101.json",
+		"/v0/item/103.json":   "hackernews_item_103.json",
+		"/v0/item/104.json":   "hackernews_item_104.json",
+	})
+
+	src, err := NewHackerNewsSourceFromModel(model.Source{
+		Name:    "HN",
+		FeedURL: srv.URL + "/v0/topstories.json",
+		Options: json.RawMessage(`{"min_score": 100}`),
+	}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewHackerNewsSourceFromModel() error = %v", err)
+	}
+
+	items, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	want := []model.Item{
+		{
+			Title:      "Go 1.99 released",
+			Link:       "https://go.dev/blog/go1.99",
+			Date:       time.Unix(1759320000, 0).UTC(),
+			Author:     "gopher",
+			Score:      500,
+			Comments:   120,
+			SourceName: "HN",
+		},
+		{
+			Title:      "Text only story",
+			Link:       hackerNewsItemURL + "104",
+			Date:       time.Unix(1759316400, 0).UTC(),
+			Summary:    "Some text",
+			Author:     "writer",
+			Score:      150,
+			Comments:   10,
+			SourceName: "HN",
+		},
+	}
+	assertItems(t, items, want)
+}
+
+func TestHackerNewsFirebaseAllItemsFail(t *testing.T) {
+	srv := serveFixtures(t, map[string]string{"/v0/topstories.json": "hackernews_topstories.json"})
+
+	src, err := NewHackerNewsSourceFromModel(model.Source{FeedURL: srv.URL + "/v0/topstories.json"}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewHackerNewsSourceFromModel() error = %v", err)
+	}
+
+	if _, err := src.Fetch(context.Background()); err == nil {
+		t.Fatal("Fetch() error = nil, want an error when no item can be fetched")
+	}
+}
+
+func assertItems(t *testing.T, got []model.Item, want []model.Item) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(got), len(want), got)
+	}
+
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Title != w.Title || g.Link != w.Link || !g.Date.Equal(w.Date) || g.Summary != w.Summary ||
+			g.Author != w.Author || g.Score != w.Score || g.Comments != w.Comments || g.SourceName != w.SourceName ||
+			len(g.Categories) != len(w.Categories) {
+			t.Errorf("item %d = %+v, want %+v", i, g, w)
+			continue
+		}
+		for j := range w.Categories {
+			if g.Categories[j] != w.Categories[j] {
+				t.Errorf("item %d categories = %v, want %v", i, g.Categories, w.Categories)
+			}
+		}
+	}
+}
+
+func TestHackerNewsDefaultLimit(t *testing.T) {
+	tests := []struct {
+		url  string
+		want int
+	}{
+		{url: "https://hacker-news.firebaseio.com/v0/topstories.json", want: defaultFirebaseLimit},
+		{url: "https://hn.algolia.com/api/v1/search?tags=front_page", want: defaultScoreLimit},
+	}
+
+	for _, tt := range tests {
+		src, err := NewHackerNewsSourceFromModel(model.Source{FeedURL: tt.url}, http.DefaultClient)
+		if err != nil {
+			t.Fatalf("NewHackerNewsSourceFromModel() error = %v", err)
+		}
+		if src.options.Limit != tt.want {
+			t.Errorf("limit of %s = %d, want %d", tt.url, src.options.Limit, tt.want)
+		}
+	}
+}
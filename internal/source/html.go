@@ -0,0 +1,230 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/araddon/dateparse"
+	"github.com/go-shiori/dom"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+	"golang.org/x/net/html"
+)
+
+// HTMLOptions configures how items are scraped from a plain HTML list page.
+// Every selector except Item is applied inside the matched item container.
+type HTMLOptions struct {
+	Item    string `json:"item"`
+	Title   string `json:"title"`
+	Link    string `json:"link"`
+	Date    string `json:"date"`
+	Summary string `json:"summary"`
+	// DateLayout is a Go time layout for dates that the automatic parsing gets wrong.
+	DateLayout string `json:"date_layout"`
+}
+
+type htmlSelectors struct {
+	item    cascadia.Selector
+	title   cascadia.Selector
+	link    cascadia.Selector
+	date    cascadia.Selector
+	summary cascadia.Selector
+}
+
+type HTMLSource struct {
+	httpFeed
+
+	URL        string
+	SourceID   int64
+	SourceName string
+	options    HTMLOptions
+	selectors  htmlSelectors
+}
+
+func NewHTMLSourceFromModel(m model.Source, client *http.Client) (HTMLSource, error) {
+	var options HTMLOptions
+	if len(m.Options) > 0 {
+		if err := json.Unmarshal(m.Options, &options); err != nil {
+			return HTMLSource{}, fmt.Errorf("invalid html source options: %w", err)
+		}
+	}
+
+	selectors, err := compileHTMLSelectors(options)
+	if err != nil {
+		return HTMLSource{}, err
+	}
+
+	return HTMLSource{
+		httpFeed:   newHTTPFeed(client, Validators{ETag: m.ETag, LastModified: m.LastModified}),
+		URL:        m.FeedURL,
+		SourceID:   m.ID,
+		SourceName: m.Name,
+		options:    options,
+		selectors:  selectors,
+	}, nil
+}
+
+func compileHTMLSelectors(options HTMLOptions) (htmlSelectors, error) {
+	if options.Item == "" || options.Title == "" {
+		return htmlSelectors{}, errors.New("html source needs at least the item and title selectors")
+	}
+
+	var selectors htmlSelectors
+	fields := []struct {
+		name     string
+		selector string
+		target   *cascadia.Selector
+		optional bool
+	}{
+		{"item", options.Item, &selectors.item, false},
+		{"title", options.Title, &selectors.title, false},
+		{"link", options.Link, &selectors.link, true},
+		{"date", options.Date, &selectors.date, true},
+		{"summary", options.Summary, &selectors.summary, true},
+	}
+
+	for _, field := range fields {
+		if field.selector == "" && field.optional {
+			continue
+		}
+
+		compiled, err := cascadia.Compile(field.selector)
+		if err != nil {
+			return htmlSelectors{}, fmt.Errorf("invalid %s selector %q: %w", field.name, field.selector, err)
+		}
+		*field.target = compiled
+	}
+
+	return selectors, nil
+}
+
+func (s HTMLSource) Fetch(ctx context.Context) ([]model.Item, error) {
+	doc, err := s.fetch(ctx, s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := html.Parse(bytes.NewReader(doc.body))
+	if err != nil {
+		return nil, err
+	}
+
+	if titleNode := cascadia.MustCompile("title").MatchFirst(root); titleNode != nil {
+		*s.title = strings.TrimSpace(dom.TextContent(titleNode))
+	}
+
+	base, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []model.Item
+	for _, node := range s.selectors.item.MatchAll(root) {
+		item := model.Item{
+			Title:      s.text(node, s.selectors.title),
+			Link:       s.link(node, base),
+			Date:       s.date(node),
+			Summary:    s.text(node, s.selectors.summary),
+			SourceName: s.SourceName,
+		}
+
+		if item.Title == "" || item.Link == "" {
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("selector %q matched no items with a title and a link", s.options.Item)
+	}
+
+	return items, nil
+}
+
+func (s HTMLSource) text(node *html.Node, selector cascadia.Selector) string {
+	if selector == nil {
+		return ""
+	}
+
+	match := selector.MatchFirst(node)
+	if match == nil {
+		return ""
+	}
+
+	return strings.Join(strings.Fields(dom.TextContent(match)), " ")
+}
+
+// link uses the href of the link selector match, of the first anchor inside
+// the item or of the item itself, in this order.
+func (s HTMLSource) link(node *html.Node, base *url.URL) string {
+	var match *html.Node
+	if s.selectors.link != nil {
+		match = s.selectors.link.MatchFirst(node)
+	} else if node.Data == "a" {
+		match = node
+	} else {
+		match = cascadia.MustCompile("a[href]").MatchFirst(node)
+	}
+
+	if match == nil {
+		return ""
+	}
+
+	href := strings.TrimSpace(dom.GetAttribute(match, "href"))
+	if href == "" {
+		return ""
+	}
+
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+
+	return base.ResolveReference(ref).String()
+}
+
+// date prefers the machine readable datetime attribute of <time> elements.
+func (s HTMLSource) date(node *html.Node) time.Time {
+	if s.selectors.date == nil {
+		return time.Time{}
+	}
+
+	match := s.selectors.date.MatchFirst(node)
+	if match == nil {
+		return time.Time{}
+	}
+
+	value := strings.TrimSpace(dom.GetAttribute(match, "datetime"))
+	if value == "" {
+		value = strings.Join(strings.Fields(dom.TextContent(match)), " ")
+	}
+
+	if s.options.DateLayout != "" {
+		if t, err := time.Parse(s.options.DateLayout, value); err == nil {
+			return t
+		}
+	}
+
+	t, err := dateparse.ParseAny(value)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+func (s HTMLSource) ID() int64 {
+	return s.SourceID
+}
+
+func (s HTMLSource) Name() string {
+	return s.SourceName
+}
@@ -0,0 +1,143 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxFeedSize caps how much of a feed response is read into memory.
+const maxFeedSize = 10 << 20
+
+// userAgent identifies the bot, some APIs (Reddit) reject requests without one.
+const userAgent = "gonewsbot/1.0 (+https://github.com/ozaitsev92/gonewsbot)"
+
+// ErrNotModified is returned by Fetch when the server answered a conditional
+// request with 304 Not Modified, so there is nothing new to parse.
+var ErrNotModified = errors.New("feed not modified")
+
+// StatusError is returned when the server answers with an unexpected status code.
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d while fetching %s", e.Code, e.URL)
+}
+
+// Validators are the HTTP cache validators of the last successful fetch.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+type document struct {
+	contentType string
+	header      http.Header
+	body        []byte
+}
+
+// httpFeed is embedded by the sources that download a document over HTTP.
+// It remembers the validators between fetches so that unchanged feeds
+// are answered with 304 and never downloaded in full.
+type httpFeed struct {
+	client     *http.Client
+	validators *Validators
+	title      *string
+	webSub     *WebSubLinks
+}
+
+func newHTTPFeed(client *http.Client, validators Validators) httpFeed {
+	return httpFeed{
+		client:     client,
+		validators: &validators,
+		title:      new(string),
+		webSub:     new(WebSubLinks),
+	}
+}
+
+// Title returns the feed title seen by the last successful fetch.
+func (f httpFeed) Title() string {
+	return *f.title
+}
+
+// CacheValidators returns the validators to persist after a fetch.
+func (f httpFeed) CacheValidators() Validators {
+	return *f.validators
+}
+
+// WebSubLinks returns the WebSub hub advertised by the document of the last successful fetch.
+func (f httpFeed) WebSubLinks() WebSubLinks {
+	return *f.webSub
+}
+
+func (f httpFeed) fetch(ctx context.Context, url string) (*document, error) {
+	doc, err := fetchDocument(ctx, f.client, url, f.validators)
+	if err != nil {
+		return nil, err
+	}
+
+	*f.webSub = webSubLinks(doc.header, doc.body)
+
+	return doc, nil
+}
+
+// do sends a prepared request with the conditional headers of the feed.
+func (f httpFeed) do(req *http.Request) (*document, error) {
+	return doRequest(f.client, req, f.validators)
+}
+
+func fetchDocument(ctx context.Context, client *http.Client, url string, validators *Validators) (*document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	return doRequest(client, req, validators)
+}
+
+func doRequest(client *http.Client, req *http.Request, validators *Validators) (*document, error) {
+	if validators != nil {
+		if validators.ETag != "" {
+			req.Header.Set("If-None-Match", validators.ETag)
+		}
+		if validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", validators.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && validators != nil {
+		return nil, ErrNotModified
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: req.URL.String(), Code: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, err
+	}
+
+	if validators != nil {
+		validators.ETag = resp.Header.Get("ETag")
+		validators.LastModified = resp.Header.Get("Last-Modified")
+	}
+
+	return &document{
+		contentType: resp.Header.Get("Content-Type"),
+		header:      resp.Header,
+		body:        body,
+	}, nil
+}
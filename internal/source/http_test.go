@@ -0,0 +1,107 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const testRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+<channel>
+	<title>Example</title>
+	<link>https://example.com</link>
+	<description>Example feed</description>
+	<item>
+		<title>First post</title>
+		<link>https://example.com/first</link>
+		<pubDate>Wed, 01 Oct 2025 12:00:00 GMT</pubDate>
+	</item>
+</channel>
+</rss>`
+
+const (
+	testETag         = `"v1"`
+	testLastModified = "Wed, 01 Oct 2025 12:00:00 GMT"
+)
+
+func TestConditionalGet(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.Header.Get("If-None-Match") == testETag && r.Header.Get("If-Modified-Since") == testLastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			t.Errorf("unexpected validators %q and %q", r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since"))
+		}
+
+		w.Header().Set("ETag", testETag)
+		w.Header().Set("Last-Modified", testLastModified)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		_, _ = w.Write([]byte(testRSS))
+	}))
+	defer srv.Close()
+
+	src := NewRSSSourceFromModel(model.Source{ID: 1, Name: "Example", FeedURL: srv.URL}, srv.Client())
+
+	items, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("first Fetch() error = %v", err)
+	}
+	if len(items) != 1 || items[0].Title != "First post" {
+		t.Fatalf("first Fetch() items = %+v, want the one post", items)
+	}
+
+	want := Validators{ETag: testETag, LastModified: testLastModified}
+	if got := src.CacheValidators(); got != want {
+		t.Fatalf("CacheValidators() after 200 = %+v, want %+v", got, want)
+	}
+
+	// The persisted validators are restored from the model by the next fetcher run.
+	validators := src.CacheValidators()
+	src = NewRSSSourceFromModel(model.Source{
+		ID:           1,
+		Name:         "Example",
+		FeedURL:      srv.URL,
+		ETag:         validators.ETag,
+		LastModified: validators.LastModified,
+	}, srv.Client())
+
+	items, err = src.Fetch(context.Background())
+	if !errors.Is(err, ErrNotModified) {
+		t.Fatalf("second Fetch() error = %v, want ErrNotModified", err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("second Fetch() items = %+v, want none", items)
+	}
+	if got := src.CacheValidators(); got != want {
+		t.Fatalf("CacheValidators() after 304 = %+v, want %+v", got, want)
+	}
+
+	if requests != 2 {
+		t.Fatalf("server got %d requests, want 2", requests)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	src := NewRSSSourceFromModel(model.Source{ID: 1, FeedURL: srv.URL}, srv.Client())
+
+	_, err := src.Fetch(context.Background())
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusNotFound {
+		t.Fatalf("Fetch() error = %v, want a 404 StatusError", err)
+	}
+}
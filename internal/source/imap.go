@@ -0,0 +1,392 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	_ "github.com/emersion/go-message/charset"
+	"github.com/emersion/go-message/mail"
+	"github.com/go-shiori/dom"
+	"github.com/go-shiori/go-readability"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+	"golang.org/x/net/html"
+)
+
+const (
+	// defaultIMAPLimit is how many unprocessed messages a fetch reads at most.
+	defaultIMAPLimit = 20
+	imapTimeout      = 30 * time.Second
+)
+
+const (
+	IMAPProcessedFlag = "flag"
+	IMAPProcessedMove = "move"
+)
+
+// IMAPDialer opens the connection to the IMAP server. TLS is set up on top
+// of it by the source for imaps:// URLs.
+type IMAPDialer func(ctx context.Context, network string, address string) (net.Conn, error)
+
+// webVersionPattern matches the "view in browser" links of newsletters.
+var webVersionPattern = regexp.MustCompile(`(?i)(view|read|open)\b.{0,20}\b(browser|online|web)|web version`)
+
+// IMAPOptions configures an IMAP newsletter source.
+type IMAPOptions struct {
+	// Password is kept out of the source URL, which shows up in the source list.
+	Password string `json:"password"`
+	// Processed is what happens to a message once its item is stored:
+	// "flag" (the default) marks it as seen, "move" moves it to MoveTo.
+	Processed string `json:"processed"`
+	MoveTo    string `json:"move_to"`
+	Limit     int    `json:"limit"`
+}
+
+// IMAPSource reads newsletters from a mailbox folder. The URL names the
+// server, the user and the folder:
+//
+//	imaps://news@example.com@imap.example.com/Newsletters
+//
+// imap:// connects without TLS and is meant for local servers. Only unseen
+// messages are read, and they are flagged or moved by MarkProcessed after
+// the fetcher has stored their items.
+type IMAPSource struct {
+	URL        string
+	SourceID   int64
+	SourceName string
+	options    IMAPOptions
+	address    string
+	useTLS     bool
+	username   string
+	password   string
+	mailbox    string
+	dial       IMAPDialer
+	fetched    *[]uint32
+}
+
+func NewIMAPSourceFromModel(m model.Source) (IMAPSource, error) {
+	var options IMAPOptions
+	if len(m.Options) > 0 {
+		if err := json.Unmarshal(m.Options, &options); err != nil {
+			return IMAPSource{}, fmt.Errorf("invalid imap source options: %w", err)
+		}
+	}
+
+	switch options.Processed {
+	case "":
+		options.Processed = IMAPProcessedFlag
+	case IMAPProcessedFlag:
+	case IMAPProcessedMove:
+		if options.MoveTo == "" {
+			return IMAPSource{}, errors.New("imap source needs move_to to move processed messages")
+		}
+	default:
+		return IMAPSource{}, fmt.Errorf("unknown processed action %q, use %q or %q", options.Processed, IMAPProcessedFlag, IMAPProcessedMove)
+	}
+	if options.Limit <= 0 {
+		options.Limit = defaultIMAPLimit
+	}
+
+	u, err := url.Parse(m.FeedURL)
+	if err != nil {
+		return IMAPSource{}, err
+	}
+
+	s := IMAPSource{
+		URL:        m.FeedURL,
+		SourceID:   m.ID,
+		SourceName: m.Name,
+		options:    options,
+		address:    u.Host,
+		username:   u.User.Username(),
+		mailbox:    strings.TrimPrefix(u.Path, "/"),
+		dial:       (&net.Dialer{Timeout: imapTimeout}).DialContext,
+		fetched:    new([]uint32),
+	}
+
+	switch u.Scheme {
+	case "imaps":
+		s.useTLS = true
+		if u.Port() == "" {
+			s.address = net.JoinHostPort(u.Hostname(), "993")
+		}
+	case "imap":
+		if u.Port() == "" {
+			s.address = net.JoinHostPort(u.Hostname(), "143")
+		}
+	default:
+		return IMAPSource{}, fmt.Errorf("%s is not an imap:// or imaps:// URL", m.FeedURL)
+	}
+
+	// The URL is shown wherever the source is listed or exported.
+	if _, ok := u.User.Password(); ok {
+		return IMAPSource{}, errors.New("imap source URL must not contain the password, set the password option instead")
+	}
+	s.password = options.Password
+	if s.mailbox == "" {
+		s.mailbox = "INBOX"
+	}
+
+	return s, nil
+}
+
+func (s IMAPSource) Fetch(ctx context.Context) ([]model.Item, error) {
+	c, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Logout()
+
+	if _, err := c.Select(s.mailbox, true); err != nil {
+		return nil, fmt.Errorf("failed to select %s: %w", s.mailbox, err)
+	}
+
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	uids, err := c.UidSearch(criteria)
+	if err != nil {
+		return nil, err
+	}
+	if len(uids) == 0 {
+		*s.fetched = nil
+		return nil, nil
+	}
+
+	// The newest messages win when the backlog is over the limit.
+	uids = uids[max(0, len(uids)-s.options.Limit):]
+
+	seqSet := new(imap.SeqSet)
+	seqSet.AddNum(uids...)
+
+	section := &imap.BodySectionName{Peek: true}
+	messages := make(chan *imap.Message, len(uids))
+	if err := c.UidFetch(seqSet, []imap.FetchItem{imap.FetchUid, section.FetchItem()}, messages); err != nil {
+		return nil, err
+	}
+
+	var (
+		items   []model.Item
+		fetched []uint32
+	)
+	for msg := range messages {
+		body := msg.GetBody(section)
+		if body == nil {
+			continue
+		}
+
+		item, err := parseNewsletter(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse message %d: %w", msg.Uid, err)
+		}
+		item.SourceName = s.SourceName
+
+		items = append(items, item)
+		fetched = append(fetched, msg.Uid)
+	}
+	*s.fetched = fetched
+
+	return items, nil
+}
+
+// MarkProcessed flags or moves the messages read by the last fetch.
+func (s IMAPSource) MarkProcessed(ctx context.Context) error {
+	if len(*s.fetched) == 0 {
+		return nil
+	}
+
+	c, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Logout()
+
+	if _, err := c.Select(s.mailbox, false); err != nil {
+		return fmt.Errorf("failed to select %s: %w", s.mailbox, err)
+	}
+
+	seqSet := new(imap.SeqSet)
+	seqSet.AddNum(*s.fetched...)
+
+	if s.options.Processed == IMAPProcessedMove {
+		err = c.UidMove(seqSet, s.options.MoveTo)
+	} else {
+		err = c.UidStore(seqSet, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{imap.SeenFlag}, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	*s.fetched = nil
+
+	return nil
+}
+
+// WithDialer returns a copy of the source that connects through the dialer,
+// e.g. to reach the server through a proxy or to talk to a test server.
+func (s IMAPSource) WithDialer(dial IMAPDialer) IMAPSource {
+	s.dial = dial
+	return s
+}
+
+// connect dials and logs in. The client has no context support, so the
+// connection is closed when the context is done.
+func (s IMAPSource) connect(ctx context.Context) (*client.Client, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, imapTimeout)
+	defer cancel()
+
+	conn, err := s.dial(dialCtx, "tcp", s.address)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.useTLS {
+		host, _, _ := net.SplitHostPort(s.address)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.HandshakeContext(dialCtx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	c, err := client.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c.Timeout = imapTimeout
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Terminate()
+		case <-c.LoggedOut():
+		}
+	}()
+
+	if err := c.Login(s.username, s.password); err != nil {
+		c.Logout()
+		return nil, fmt.Errorf("imap login failed: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s IMAPSource) ID() int64 {
+	return s.SourceID
+}
+
+func (s IMAPSource) Name() string {
+	return s.SourceName
+}
+
+// parseNewsletter maps a MIME message to an item. The HTML body goes through
+// readability to drop the newsletter chrome, the plain text body is used
+// when there is no HTML one.
+func parseNewsletter(r io.Reader) (model.Item, error) {
+	mr, err := mail.CreateReader(r)
+	if err != nil {
+		return model.Item{}, err
+	}
+	defer mr.Close()
+
+	var item model.Item
+
+	item.Title, _ = mr.Header.Subject()
+	if item.Title == "" {
+		item.Title = "(no subject)"
+	}
+	if date, err := mr.Header.Date(); err == nil {
+		item.Date = date
+	}
+	if from, err := mr.Header.AddressList("From"); err == nil && len(from) > 0 {
+		item.Author = firstNonEmpty(from[0].Name, from[0].Address)
+	}
+
+	var htmlBody, textBody []byte
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return model.Item{}, err
+		}
+
+		header, ok := part.Header.(*mail.InlineHeader)
+		if !ok {
+			continue
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+		switch {
+		case mediaType == "text/html" && htmlBody == nil:
+			htmlBody, err = io.ReadAll(io.LimitReader(part.Body, maxFeedSize))
+		case (mediaType == "text/plain" || mediaType == "") && textBody == nil:
+			textBody, err = io.ReadAll(io.LimitReader(part.Body, maxFeedSize))
+		}
+		if err != nil {
+			return model.Item{}, err
+		}
+	}
+
+	if htmlBody != nil {
+		item.Link = webVersionLink(htmlBody)
+
+		article, err := readability.FromReader(bytes.NewReader(htmlBody), nil)
+		if err == nil && strings.TrimSpace(article.TextContent) != "" {
+			item.Content = article.Content
+		} else {
+			item.Content = string(htmlBody)
+		}
+	} else {
+		item.Content = string(textBody)
+	}
+	item.Summary = item.Content
+
+	// Without a web version the message id keeps the item unique (RFC 2392 mid: URL).
+	if item.Link == "" {
+		messageID, _ := mr.Header.MessageID()
+		if messageID == "" {
+			sum := sha256.Sum256([]byte(item.Title + item.Author + item.Date.String()))
+			messageID = hex.EncodeToString(sum[:16])
+		}
+		item.Link = "mid:" + url.PathEscape(messageID)
+	}
+
+	return item, nil
+}
+
+// webVersionLink returns the "view in browser" link of a newsletter, if it has one.
+func webVersionLink(body []byte) string {
+	root, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	for _, anchor := range dom.GetElementsByTagName(root, "a") {
+		href := strings.TrimSpace(dom.GetAttribute(anchor, "href"))
+		if !strings.HasPrefix(href, "http") {
+			continue
+		}
+		if webVersionPattern.MatchString(dom.TextContent(anchor)) {
+			return href
+		}
+	}
+
+	return ""
+}
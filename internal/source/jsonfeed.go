@@ -0,0 +1,148 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+type jsonFeed struct {
+	Version string         `json:"version"`
+	Title   string         `json:"title"`
+	Items   []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string               `json:"id"`
+	URL           string               `json:"url"`
+	ExternalURL   string               `json:"external_url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Authors       []jsonFeedAuthor     `json:"authors"`
+	Author        *jsonFeedAuthor      `json:"author"`
+	Tags          []string             `json:"tags"`
+	Attachments   []jsonFeedAttachment `json:"attachments"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedAttachment struct {
+	URL         string `json:"url"`
+	MimeType    string `json:"mime_type"`
+	SizeInBytes int64  `json:"size_in_bytes"`
+}
+
+type JSONFeedSource struct {
+	httpFeed
+
+	URL        string
+	SourceID   int64
+	SourceName string
+}
+
+func NewJSONFeedSourceFromModel(m model.Source, client *http.Client) JSONFeedSource {
+	return JSONFeedSource{
+		httpFeed:   newHTTPFeed(client, Validators{ETag: m.ETag, LastModified: m.LastModified}),
+		URL:        m.FeedURL,
+		SourceID:   m.ID,
+		SourceName: m.Name,
+	}
+}
+
+func (s JSONFeedSource) Fetch(ctx context.Context) ([]model.Item, error) {
+	doc, err := s.fetch(ctx, s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	title, items, err := parseJSONFeed(doc.body)
+	if err != nil {
+		return nil, err
+	}
+	*s.title = title
+
+	for i := range items {
+		items[i].SourceName = s.SourceName
+	}
+
+	return items, nil
+}
+
+func (s JSONFeedSource) ID() int64 {
+	return s.SourceID
+}
+
+func (s JSONFeedSource) Name() string {
+	return s.SourceName
+}
+
+// ParseJSONFeed parses a JSON Feed 1.0 or 1.1 document into items.
+func ParseJSONFeed(data []byte) ([]model.Item, error) {
+	_, items, err := parseJSONFeed(data)
+	return items, err
+}
+
+func parseJSONFeed(data []byte) (string, []model.Item, error) {
+	var feed jsonFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return "", nil, err
+	}
+
+	if !strings.HasPrefix(feed.Version, "https://jsonfeed.org/version/") {
+		return "", nil, fmt.Errorf("unsupported json feed version %q", feed.Version)
+	}
+
+	items := make([]model.Item, len(feed.Items))
+	for i, entry := range feed.Items {
+		item := model.Item{
+			Title:      entry.Title,
+			Link:       entry.URL,
+			Summary:    entry.Summary,
+			Content:    entry.ContentHTML,
+			Categories: entry.Tags,
+			Date:       parseRFC3339(entry.DatePublished, entry.DateModified),
+		}
+
+		if item.Link == "" {
+			item.Link = entry.ExternalURL
+		}
+		if item.Content == "" {
+			item.Content = entry.ContentText
+		}
+
+		// JSON Feed 1.1 replaced author with authors, but 1.0 feeds are still common.
+		authors := entry.Authors
+		if len(authors) == 0 && entry.Author != nil {
+			authors = []jsonFeedAuthor{*entry.Author}
+		}
+		names := make([]string, 0, len(authors))
+		for _, author := range authors {
+			if author.Name != "" {
+				names = append(names, author.Name)
+			}
+		}
+		item.Author = strings.Join(names, ", ")
+
+		for _, attachment := range entry.Attachments {
+			item.Enclosures = append(item.Enclosures, model.Enclosure{
+				URL:    attachment.URL,
+				Type:   attachment.MimeType,
+				Length: attachment.SizeInBytes,
+			})
+		}
+
+		items[i] = item
+	}
+
+	return feed.Title, items, nil
+}
@@ -0,0 +1,418 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+	"golang.org/x/net/html"
+)
+
+const (
+	// mastodonPageSize is the largest page the Mastodon API returns.
+	mastodonPageSize = 40
+	// mastodonMaxPages bounds how far a fetch pages back to the cursor.
+	mastodonMaxPages = 5
+	// mastodonTitleLength is how much of a status becomes the item title.
+	mastodonTitleLength = 100
+)
+
+// MastodonOptions configures a Mastodon source.
+type MastodonOptions struct {
+	ExcludeBoosts  bool `json:"exclude_boosts"`
+	ExcludeReplies bool `json:"exclude_replies"`
+	// Local limits the public timeline to the statuses of the instance itself.
+	Local bool `json:"local"`
+	// Token is an access token for instances that hide their timelines from anonymous clients.
+	Token string `json:"token"`
+}
+
+type mastodonStatus struct {
+	ID              string          `json:"id"`
+	CreatedAt       time.Time       `json:"created_at"`
+	URL             string          `json:"url"`
+	URI             string          `json:"uri"`
+	Content         string          `json:"content"`
+	SpoilerText     string          `json:"spoiler_text"`
+	InReplyToID     *string         `json:"in_reply_to_id"`
+	Reblog          *mastodonStatus `json:"reblog"`
+	Account         mastodonAccount `json:"account"`
+	Tags            []mastodonTag   `json:"tags"`
+	Media           []mastodonMedia `json:"media_attachments"`
+	RepliesCount    int             `json:"replies_count"`
+	ReblogsCount    int             `json:"reblogs_count"`
+	FavouritesCount int             `json:"favourites_count"`
+}
+
+type mastodonAccount struct {
+	ID          string `json:"id"`
+	Acct        string `json:"acct"`
+	DisplayName string `json:"display_name"`
+}
+
+type mastodonTag struct {
+	Name string `json:"name"`
+}
+
+type mastodonMedia struct {
+	Type       string `json:"type"`
+	URL        string `json:"url"`
+	PreviewURL string `json:"preview_url"`
+}
+
+// MastodonAccounts remembers the accounts looked up by their address, so that
+// an account source resolves its account id once instead of on every fetch.
+// The id of an account never changes on its instance.
+type MastodonAccounts struct {
+	mu       sync.Mutex
+	accounts map[string]mastodonAccount
+}
+
+func NewMastodonAccounts() *MastodonAccounts {
+	return &MastodonAccounts{accounts: make(map[string]mastodonAccount)}
+}
+
+func (a *MastodonAccounts) get(key string) (mastodonAccount, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	account, ok := a.accounts[key]
+	return account, ok
+}
+
+func (a *MastodonAccounts) put(key string, account mastodonAccount) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.accounts[key] = account
+}
+
+// MastodonSource follows an account, a hashtag or the public timeline of a
+// Mastodon compatible instance through its REST API. The URL is the web
+// address of what to follow:
+//
+//	https://mastodon.social/@Gargron
+//	https://mastodon.social/tags/golang
+//	https://mastodon.social/public
+//
+// The id of the newest status seen is kept as the cursor, so that every
+// fetch only asks for the statuses posted since.
+type MastodonSource struct {
+	httpFeed
+
+	URL        string
+	SourceID   int64
+	SourceName string
+	options    MastodonOptions
+	cursor     *string
+	accounts   *MastodonAccounts
+}
+
+func NewMastodonSourceFromModel(m model.Source, client *http.Client) (MastodonSource, error) {
+	var options MastodonOptions
+	if len(m.Options) > 0 {
+		if err := json.Unmarshal(m.Options, &options); err != nil {
+			return MastodonSource{}, fmt.Errorf("invalid mastodon source options: %w", err)
+		}
+	}
+
+	cursor := m.Cursor
+
+	return MastodonSource{
+		httpFeed:   newHTTPFeed(client, Validators{ETag: m.ETag, LastModified: m.LastModified}),
+		URL:        m.FeedURL,
+		SourceID:   m.ID,
+		SourceName: m.Name,
+		options:    options,
+		cursor:     &cursor,
+		accounts:   NewMastodonAccounts(),
+	}, nil
+}
+
+// WithAccounts returns a copy of the source that shares the looked up
+// accounts, the sources are created anew for every fetch.
+func (s MastodonSource) WithAccounts(accounts *MastodonAccounts) MastodonSource {
+	s.accounts = accounts
+	return s
+}
+
+// Cursor returns the id of the newest status seen by the last fetch.
+func (s MastodonSource) Cursor() string {
+	return *s.cursor
+}
+
+func (s MastodonSource) Fetch(ctx context.Context) ([]model.Item, error) {
+	endpoint, title, err := s.timelineURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	*s.title = title
+
+	statuses, err := s.fetchSince(ctx, endpoint, *s.cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(statuses) > 0 {
+		*s.cursor = statuses[0].ID
+	}
+
+	items := make([]model.Item, 0, len(statuses))
+	for _, status := range statuses {
+		if status.Reblog != nil {
+			if s.options.ExcludeBoosts {
+				continue
+			}
+			status = *status.Reblog
+		}
+
+		if s.options.ExcludeReplies && status.InReplyToID != nil {
+			continue
+		}
+
+		item, ok := mastodonItem(status)
+		if !ok {
+			continue
+		}
+		item.SourceName = s.SourceName
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// timelineURL maps the web URL of the source to its API endpoint.
+func (s MastodonSource) timelineURL(ctx context.Context) (*url.URL, string, error) {
+	u, err := url.Parse(strings.TrimSpace(s.URL))
+	if err != nil {
+		return nil, "", err
+	}
+	if u.Host == "" {
+		return nil, "", fmt.Errorf("%s is not a Mastodon URL", s.URL)
+	}
+
+	api := &url.URL{Scheme: u.Scheme, Host: u.Host}
+	query := url.Values{}
+	path := strings.Trim(u.Path, "/")
+
+	var title string
+	switch {
+	case strings.HasPrefix(path, "@"):
+		account, err := s.lookupAccount(ctx, api, strings.TrimPrefix(path, "@"))
+		if err != nil {
+			return nil, "", err
+		}
+
+		api.Path = "/api/v1/accounts/" + url.PathEscape(account.ID) + "/statuses"
+		if s.options.ExcludeReplies {
+			query.Set("exclude_replies", "true")
+		}
+		if s.options.ExcludeBoosts {
+			query.Set("exclude_reblogs", "true")
+		}
+		title = firstNonEmpty(account.DisplayName, account.Acct)
+	case strings.HasPrefix(path, "tags/"):
+		tag := strings.TrimPrefix(path, "tags/")
+		api.Path = "/api/v1/timelines/tag/" + url.PathEscape(tag)
+		title = "#" + tag
+	case path == "public" || path == "public/local":
+		api.Path = "/api/v1/timelines/public"
+		if s.options.Local || path == "public/local" {
+			query.Set("local", "true")
+		}
+		title = u.Host
+	default:
+		return nil, "", fmt.Errorf("%s is not a Mastodon account, hashtag or public timeline URL", s.URL)
+	}
+
+	query.Set("limit", strconv.Itoa(mastodonPageSize))
+	api.RawQuery = query.Encode()
+
+	return api, title, nil
+}
+
+func (s MastodonSource) lookupAccount(ctx context.Context, api *url.URL, acct string) (mastodonAccount, error) {
+	key := api.Host + "/" + strings.ToLower(acct)
+	if account, ok := s.accounts.get(key); ok {
+		return account, nil
+	}
+
+	lookup := *api
+	lookup.Path = "/api/v1/accounts/lookup"
+	lookup.RawQuery = url.Values{"acct": {acct}}.Encode()
+
+	var account mastodonAccount
+	if err := s.get(ctx, lookup.String(), &account); err != nil {
+		return mastodonAccount{}, err
+	}
+	if account.ID == "" {
+		return mastodonAccount{}, fmt.Errorf("mastodon account %s not found", acct)
+	}
+	s.accounts.put(key, account)
+
+	return account, nil
+}
+
+// fetchSince pages back from the newest status until it reaches the one with
+// the sinceID. Without a cursor only the first page is read, so that adding
+// a source does not flood the channel with old posts.
+func (s MastodonSource) fetchSince(ctx context.Context, endpoint *url.URL, sinceID string) ([]mastodonStatus, error) {
+	var statuses []mastodonStatus
+	maxID := ""
+
+	for page := 0; page < mastodonMaxPages; page++ {
+		pageURL := *endpoint
+		query := pageURL.Query()
+		if sinceID != "" {
+			query.Set("since_id", sinceID)
+		}
+		if maxID != "" {
+			query.Set("max_id", maxID)
+		}
+		pageURL.RawQuery = query.Encode()
+
+		var batch []mastodonStatus
+		if err := s.get(ctx, pageURL.String(), &batch); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, batch...)
+
+		if sinceID == "" || len(batch) < mastodonPageSize {
+			break
+		}
+		maxID = batch[len(batch)-1].ID
+	}
+
+	return statuses, nil
+}
+
+// get reads an API response without conditional headers: the cursor in the
+// query already limits the response to what is new.
+func (s MastodonSource) get(ctx context.Context, apiURL string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	if s.options.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.options.Token)
+	}
+
+	doc, err := doRequest(s.client, req, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(doc.body, target); err != nil {
+		return fmt.Errorf("invalid mastodon api response: %w", err)
+	}
+
+	return nil
+}
+
+func (s MastodonSource) ID() int64 {
+	return s.SourceID
+}
+
+func (s MastodonSource) Name() string {
+	return s.SourceName
+}
+
+func mastodonItem(status mastodonStatus) (model.Item, bool) {
+	text := htmlText(status.Content)
+	if text == "" && len(status.Media) == 0 {
+		return model.Item{}, false
+	}
+
+	title := firstNonEmpty(status.SpoilerText, firstLine(text))
+	if title == "" {
+		title = "Media from " + status.Account.Acct
+	}
+
+	item := model.Item{
+		Title:    truncateText(title, mastodonTitleLength),
+		Link:     firstNonEmpty(status.URL, status.URI),
+		Date:     status.CreatedAt,
+		Summary:  status.Content,
+		Content:  status.Content,
+		Author:   firstNonEmpty(status.Account.DisplayName, status.Account.Acct),
+		Score:    status.FavouritesCount + status.ReblogsCount,
+		Comments: status.RepliesCount,
+	}
+
+	for _, tag := range status.Tags {
+		item.Categories = append(item.Categories, tag.Name)
+	}
+
+	for _, media := range status.Media {
+		if item.Thumbnail == "" && media.PreviewURL != "" {
+			item.Thumbnail = media.PreviewURL
+		}
+		if media.Type != "image" {
+			item.Enclosures = append(item.Enclosures, model.Enclosure{URL: media.URL, Type: media.Type})
+		}
+	}
+
+	return item, item.Link != ""
+}
+
+// htmlText returns the text of status HTML with paragraphs and line breaks kept as new lines.
+func htmlText(content string) string {
+	root, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch {
+		case node.Type == html.TextNode:
+			buf.WriteString(node.Data)
+		case node.Type == html.ElementNode && node.Data == "br":
+			buf.WriteString("\n")
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+
+		if node.Type == html.ElementNode && node.Data == "p" {
+			buf.WriteString("\n\n")
+		}
+	}
+	walk(root)
+
+	return strings.TrimSpace(buf.String())
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return strings.TrimSpace(line)
+}
+
+// truncateText shortens the text to at most limit runes, cutting at a word boundary when possible.
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	cut := string(runes[:limit-1])
+	if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+
+	return strings.TrimSpace(cut) + "…"
+}
@@ -0,0 +1,185 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// serveMastodon answers the account lookup and the statuses of the golang
+// account with the recorded fixtures and counts the lookups.
+func serveMastodon(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var lookups atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var name string
+		switch r.URL.Path {
+		case "/api/v1/accounts/lookup":
+			lookups.Add(1)
+			if r.URL.Query().Get("acct") != "golang" {
+				http.NotFound(w, r)
+				return
+			}
+			name = "mastodon_account.json"
+		case "/api/v1/accounts/42/statuses":
+			name = "mastodon_statuses.json"
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Errorf("read fixture: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &lookups
+}
+
+func newTestMastodonSource(t *testing.T, srv *httptest.Server, options string) MastodonSource {
+	t.Helper()
+
+	src, err := NewMastodonSourceFromModel(model.Source{
+		Name:    "Go on Mastodon",
+		FeedURL: srv.URL + "/@golang",
+		Type:    model.SourceTypeMastodon,
+		Options: json.RawMessage(options),
+	}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewMastodonSourceFromModel() error = %v", err)
+	}
+
+	return src
+}
+
+func TestMastodonStatuses(t *testing.T) {
+	release := model.Item{
+		Title:      "Go 1.25 is released!",
+		Link:       "https://mastodon.example/@golang/106",
+		Date:       time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC),
+		Summary:    `<p>Go 1.25 is released!</p><p>Read the <a href="https://go.dev/doc/go1.25">release notes</a>.</p>`,
+		Author:     "The Go Programming Language",
+		Score:      100,
+		Comments:   12,
+		Categories: []string{"golang", "release"},
+		SourceName: "Go on Mastodon",
+	}
+	// The boosted status of another account, titled with its content warning.
+	boost := model.Item{
+		Title:      "Benchmarks inside",
+		Link:       "https://other.example/@alice/99",
+		Date:       time.Date(2025, 10, 1, 10, 0, 0, 0, time.UTC),
+		Summary:    "<p>Profiling the new garbage collector<br>with pprof</p>",
+		Author:     "alice@other.example",
+		Score:      5,
+		Comments:   1,
+		SourceName: "Go on Mastodon",
+	}
+	reply := model.Item{
+		Title:      "@bob It ships in the next minor release.",
+		Link:       "https://mastodon.example/@golang/104",
+		Date:       time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC),
+		Summary:    "<p>@bob It ships in the next minor release.</p>",
+		Author:     "The Go Programming Language",
+		Score:      1,
+		SourceName: "Go on Mastodon",
+	}
+	// A status without a web page links to its ActivityPub id.
+	withoutURL := model.Item{
+		Title:      "Gopher stickers at the conference booth",
+		Link:       "https://mastodon.example/users/golang/statuses/103",
+		Date:       time.Date(2025, 10, 1, 8, 0, 0, 0, time.UTC),
+		Summary:    "<p>Gopher stickers at the conference booth</p>",
+		Author:     "The Go Programming Language",
+		SourceName: "Go on Mastodon",
+	}
+	media := model.Item{
+		Title:      "Media from golang",
+		Link:       "https://mastodon.example/@golang/101",
+		Date:       time.Date(2025, 10, 1, 6, 0, 0, 0, time.UTC),
+		Author:     "The Go Programming Language",
+		Score:      5,
+		SourceName: "Go on Mastodon",
+	}
+
+	tests := []struct {
+		name    string
+		options string
+		want    []model.Item
+	}{
+		{name: "all statuses", want: []model.Item{release, boost, reply, withoutURL, media}},
+		{name: "without boosts", options: `{"exclude_boosts": true}`, want: []model.Item{release, reply, withoutURL, media}},
+		{name: "without replies", options: `{"exclude_replies": true}`, want: []model.Item{release, boost, withoutURL, media}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := serveMastodon(t)
+			src := newTestMastodonSource(t, srv, tt.options)
+
+			items, err := src.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			// The status that links nowhere is left out.
+			assertItems(t, items, tt.want)
+
+			if got := items[len(items)-1].Thumbnail; got != "https://files.mastodon.example/gopher_small.png" {
+				t.Errorf("thumbnail of the media status = %q", got)
+			}
+			if got := src.Cursor(); got != "106" {
+				t.Errorf("Cursor() = %q, want the newest status", got)
+			}
+			if got := src.Title(); got != "The Go Programming Language" {
+				t.Errorf("Title() = %q, want the display name of the account", got)
+			}
+		})
+	}
+}
+
+func TestMastodonAccountLookedUpOnce(t *testing.T) {
+	srv, lookups := serveMastodon(t)
+	accounts := NewMastodonAccounts()
+
+	// Every fetch creates the source anew from the stored model.
+	for range 3 {
+		src := newTestMastodonSource(t, srv, "").WithAccounts(accounts)
+		if _, err := src.Fetch(context.Background()); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+
+	if got := lookups.Load(); got != 1 {
+		t.Fatalf("account looked up %d times, want once", got)
+	}
+}
+
+func TestMastodonUnknownAccount(t *testing.T) {
+	srv, _ := serveMastodon(t)
+
+	src, err := NewMastodonSourceFromModel(model.Source{FeedURL: srv.URL + "/@nobody"}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewMastodonSourceFromModel() error = %v", err)
+	}
+
+	if _, err := src.Fetch(context.Background()); err == nil {
+		t.Fatal("Fetch() error = nil, want the failed lookup")
+	}
+}
@@ -0,0 +1,226 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const itunesNamespace = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+// The namespaced fields come first: encoding/xml assigns an element to the
+// first matching field, and a field without a namespace matches any of them.
+type podcastFeed struct {
+	Channel struct {
+		ITunesImage podcastImage  `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+		ITunesTitle string        `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+		Title       string        `xml:"title"`
+		Image       podcastImage  `xml:"image"`
+		Items       []podcastItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type podcastItem struct {
+	ITunesTitle    string             `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	ITunesSummary  string             `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	ITunesDuration string             `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesImage    podcastImage       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	ITunesAuthor   string             `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+	MediaThumbnail mediaThumbnail     `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaContent   []mediaContent     `xml:"http://search.yahoo.com/mrss/ content"`
+	Title          string             `xml:"title"`
+	Link           string             `xml:"link"`
+	GUID           string             `xml:"guid"`
+	PubDate        string             `xml:"pubDate"`
+	Description    string             `xml:"description"`
+	Author         string             `xml:"author"`
+	Categories     []string           `xml:"category"`
+	Enclosures     []podcastEnclosure `xml:"enclosure"`
+}
+
+// podcastImage covers both <itunes:image href="..."/> and <image><url>...</url></image>.
+type podcastImage struct {
+	Href string `xml:"href,attr"`
+	URL  string `xml:"url"`
+}
+
+func (i podcastImage) String() string {
+	if i.Href != "" {
+		return strings.TrimSpace(i.Href)
+	}
+	return strings.TrimSpace(i.URL)
+}
+
+type podcastEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+type mediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+type mediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Medium   string `xml:"medium,attr"`
+	FileSize string `xml:"fileSize,attr"`
+	Duration string `xml:"duration,attr"`
+}
+
+// PodcastSource reads an RSS podcast feed including the iTunes and Media RSS
+// extensions, which carry the episode duration and artwork.
+type PodcastSource struct {
+	httpFeed
+
+	URL        string
+	SourceID   int64
+	SourceName string
+}
+
+func NewPodcastSourceFromModel(m model.Source, client *http.Client) PodcastSource {
+	return PodcastSource{
+		httpFeed:   newHTTPFeed(client, Validators{ETag: m.ETag, LastModified: m.LastModified}),
+		URL:        m.FeedURL,
+		SourceID:   m.ID,
+		SourceName: m.Name,
+	}
+}
+
+func (s PodcastSource) Fetch(ctx context.Context) ([]model.Item, error) {
+	doc, err := s.fetch(ctx, s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	title, items, err := parsePodcast(doc.body)
+	if err != nil {
+		return nil, err
+	}
+	*s.title = title
+
+	for i := range items {
+		items[i].SourceName = s.SourceName
+	}
+
+	return items, nil
+}
+
+func (s PodcastSource) ID() int64 {
+	return s.SourceID
+}
+
+func (s PodcastSource) Name() string {
+	return s.SourceName
+}
+
+// ParsePodcast parses an RSS podcast feed into items.
+func ParsePodcast(data []byte) ([]model.Item, error) {
+	_, items, err := parsePodcast(data)
+	return items, err
+}
+
+func parsePodcast(data []byte) (string, []model.Item, error) {
+	var feed podcastFeed
+	decoder := newXMLDecoder(data)
+	if err := decoder.Decode(&feed); err != nil {
+		return "", nil, fmt.Errorf("invalid podcast feed: %w", err)
+	}
+
+	// The channel artwork stands in for episodes without their own.
+	channelImage := feed.Channel.ITunesImage.String()
+	if channelImage == "" {
+		channelImage = feed.Channel.Image.String()
+	}
+
+	items := make([]model.Item, 0, len(feed.Channel.Items))
+	for _, entry := range feed.Channel.Items {
+		item := model.Item{
+			Title:      firstNonEmpty(entry.Title, entry.ITunesTitle),
+			Link:       strings.TrimSpace(entry.Link),
+			Summary:    firstNonEmpty(entry.Description, entry.ITunesSummary),
+			Author:     firstNonEmpty(entry.ITunesAuthor, entry.Author),
+			Categories: entry.Categories,
+			Duration:   parseMediaDuration(entry.ITunesDuration),
+			Thumbnail:  firstNonEmpty(entry.ITunesImage.String(), entry.MediaThumbnail.URL, channelImage),
+		}
+
+		if date, err := dateparse.ParseAny(strings.TrimSpace(entry.PubDate)); err == nil {
+			item.Date = date
+		}
+
+		for _, enclosure := range entry.Enclosures {
+			length, _ := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64)
+			item.Enclosures = append(item.Enclosures, model.Enclosure{
+				URL:    strings.TrimSpace(enclosure.URL),
+				Type:   enclosure.Type,
+				Length: length,
+			})
+		}
+		for _, content := range entry.MediaContent {
+			if content.Medium == "image" || strings.HasPrefix(content.Type, "image/") {
+				continue
+			}
+			length, _ := strconv.ParseInt(content.FileSize, 10, 64)
+			item.Enclosures = append(item.Enclosures, model.Enclosure{
+				URL:    strings.TrimSpace(content.URL),
+				Type:   content.Type,
+				Length: length,
+			})
+			if item.Duration == 0 {
+				item.Duration = parseMediaDuration(content.Duration)
+			}
+		}
+
+		// Episodes often have no web page, a permalink guid or the audio file is the link then.
+		if item.Link == "" && strings.HasPrefix(strings.TrimSpace(entry.GUID), "http") {
+			item.Link = strings.TrimSpace(entry.GUID)
+		}
+		if item.Link == "" && len(item.Enclosures) > 0 {
+			item.Link = item.Enclosures[0].URL
+		}
+
+		if item.Title == "" || item.Link == "" {
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	return firstNonEmpty(feed.Channel.Title, feed.Channel.ITunesTitle), items, nil
+}
+
+// parseMediaDuration reads durations given in seconds or as [[HH:]MM:]SS.
+func parseMediaDuration(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	var seconds float64
+	for _, part := range strings.Split(value, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"net/http"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const redditBaseURL = "https://www.reddit.com"
+
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Data redditPost `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type redditPost struct {
+	Title       string  `json:"title"`
+	URL         string  `json:"url"`
+	Permalink   string  `json:"permalink"`
+	Author      string  `json:"author"`
+	Score       int     `json:"score"`
+	NumComments int     `json:"num_comments"`
+	Selftext    string  `json:"selftext"`
+	CreatedUTC  float64 `json:"created_utc"`
+	Flair       string  `json:"link_flair_text"`
+	Over18      bool    `json:"over_18"`
+	Stickied    bool    `json:"stickied"`
+}
+
+// RedditSource reads a JSON listing such as https://www.reddit.com/r/golang/top.json?t=day.
+// Stickied and NSFW posts are always skipped.
+type RedditSource struct {
+	httpFeed
+
+	URL        string
+	SourceID   int64
+	SourceName string
+	options    ScoreOptions
+}
+
+func NewRedditSourceFromModel(m model.Source, client *http.Client) (RedditSource, error) {
+	options, err := parseScoreOptions(m.Options, defaultScoreLimit)
+	if err != nil {
+		return RedditSource{}, err
+	}
+
+	return RedditSource{
+		httpFeed:   newHTTPFeed(client, Validators{ETag: m.ETag, LastModified: m.LastModified}),
+		URL:        m.FeedURL,
+		SourceID:   m.ID,
+		SourceName: m.Name,
+		options:    options,
+	}, nil
+}
+
+func (s RedditSource) Fetch(ctx context.Context) ([]model.Item, error) {
+	doc, err := s.fetch(ctx, s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	var listing redditListing
+	if err := json.Unmarshal(doc.body, &listing); err != nil {
+		return nil, err
+	}
+
+	*s.title = "Reddit"
+
+	children := listing.Data.Children[:min(len(listing.Data.Children), s.options.Limit)]
+	items := make([]model.Item, 0, len(children))
+	for _, child := range children {
+		post := child.Data
+		if post.Stickied || post.Over18 {
+			continue
+		}
+
+		item := model.Item{
+			Title:      html.UnescapeString(post.Title),
+			Link:       html.UnescapeString(post.URL),
+			Date:       time.Unix(int64(post.CreatedUTC), 0).UTC(),
+			Summary:    post.Selftext,
+			Author:     post.Author,
+			Score:      post.Score,
+			Comments:   post.NumComments,
+			SourceName: s.SourceName,
+		}
+		if item.Link == "" {
+			item.Link = redditBaseURL + post.Permalink
+		}
+		if post.Flair != "" {
+			item.Categories = []string{post.Flair}
+		}
+
+		if s.options.accepts(item) {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func (s RedditSource) ID() int64 {
+	return s.SourceID
+}
+
+func (s RedditSource) Name() string {
+	return s.SourceName
+}
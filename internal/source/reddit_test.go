@@ -0,0 +1,75 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+func TestRedditListing(t *testing.T) {
+	srv := serveFixtures(t, map[string]string{"/r/golang/top.json": "reddit_listing.json"})
+
+	src, err := NewRedditSourceFromModel(model.Source{
+		Name:    "r/golang",
+		FeedURL: srv.URL + "/r/golang/top.json?t=day",
+		Options: json.RawMessage(`{"min_score": 100, "min_comments": 10}`),
+	}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRedditSourceFromModel() error = %v", err)
+	}
+
+	items, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	// The stickied, the NSFW and the low score posts are dropped.
+	want := []model.Item{
+		{
+			Title:      "Generics & iterators in practice",
+			Link:       "https://example.com/generics?a=1&b=2",
+			Date:       time.Unix(1759316400, 0).UTC(),
+			Author:     "alice",
+			Score:      420,
+			Comments:   64,
+			Categories: []string{"show & tell"},
+			SourceName: "r/golang",
+		},
+		{
+			Title:      "Self post question",
+			Link:       redditBaseURL + "/r/golang/comments/4/question/",
+			Date:       time.Unix(1759309200, 0).UTC(),
+			Summary:    "How do I ...?",
+			Author:     "carol",
+			Score:      150,
+			Comments:   30,
+			SourceName: "r/golang",
+		},
+	}
+	assertItems(t, items, want)
+}
+
+func TestRedditLimit(t *testing.T) {
+	srv := serveFixtures(t, map[string]string{"/r/golang/top.json": "reddit_listing.json"})
+
+	src, err := NewRedditSourceFromModel(model.Source{
+		FeedURL: srv.URL + "/r/golang/top.json",
+		Options: json.RawMessage(`{"limit": 2}`),
+	}, srv.Client())
+	if err != nil {
+		t.Fatalf("NewRedditSourceFromModel() error = %v", err)
+	}
+
+	items, err := src.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	// The limit counts the listing entries, the stickied one among them.
+	if len(items) != 1 || items[0].Author != "alice" {
+		t.Fatalf("Fetch() = %+v, want the post of alice only", items)
+	}
+}
@@ -2,24 +2,23 @@ package source
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/SlyMarbo/rss"
 	"github.com/ozaitsev92/gonewsbot/internal/model"
 )
 
-type response struct {
-	feed *rss.Feed
-	err  error
-}
-
 type RSSSource struct {
+	httpFeed
+
 	URL        string
 	SourceID   int64
 	SourceName string
 }
 
-func NewRSSSourceFromModel(m model.Source) RSSSource {
+func NewRSSSourceFromModel(m model.Source, client *http.Client) RSSSource {
 	return RSSSource{
+		httpFeed:   newHTTPFeed(client, Validators{ETag: m.ETag, LastModified: m.LastModified}),
 		URL:        m.FeedURL,
 		SourceID:   m.ID,
 		SourceName: m.Name,
@@ -31,40 +30,48 @@ func (s RSSSource) Fetch(ctx context.Context) ([]model.Item, error) {
 	if err != nil {
 		return nil, err
 	}
+	*s.title = feed.Title
 
+	return rssItems(feed, s.SourceName), nil
+}
+
+func rssItems(feed *rss.Feed, sourceName string) []model.Item {
 	items := make([]model.Item, len(feed.Items))
 	for i, item := range feed.Items {
+		enclosures := make([]model.Enclosure, 0, len(item.Enclosures))
+		for _, enclosure := range item.Enclosures {
+			enclosures = append(enclosures, model.Enclosure{
+				URL:    enclosure.URL,
+				Type:   enclosure.Type,
+				Length: int64(enclosure.Length),
+			})
+		}
+
 		items[i] = model.Item{
 			Title:      item.Title,
 			Categories: item.Categories,
 			Link:       item.Link,
 			Date:       item.Date,
 			Summary:    item.Summary,
-			SourceName: s.SourceName,
+			Content:    item.Content,
+			Enclosures: enclosures,
+			SourceName: sourceName,
+		}
+		if item.Image != nil {
+			items[i].Thumbnail = item.Image.URL
 		}
 	}
 
-	return items, nil
+	return items
 }
 
 func (s RSSSource) loadFeed(ctx context.Context, url string) (*rss.Feed, error) {
-	resCh := make(chan response)
-
-	go func() {
-		feed, err := rss.Fetch(url) // todo: inject this as a dependency
-		res := response{
-			feed: feed,
-			err:  err,
-		}
-		resCh <- res
-	}()
-
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case res := <-resCh:
-		return res.feed, res.err
+	doc, err := s.fetch(ctx, url)
+	if err != nil {
+		return nil, err
 	}
+
+	return rss.Parse(doc.body)
 }
 
 func (s RSSSource) ID() int64 {
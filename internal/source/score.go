@@ -0,0 +1,38 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// defaultScoreLimit is how many submissions a ranked source looks at per fetch.
+const defaultScoreLimit = 30
+
+// ScoreOptions are the thresholds of sources that rank submissions by votes.
+type ScoreOptions struct {
+	MinScore    int `json:"min_score"`
+	MinComments int `json:"min_comments"`
+	Limit       int `json:"limit"`
+}
+
+// parseScoreOptions falls back to defaultLimit when the options set no limit.
+func parseScoreOptions(raw json.RawMessage, defaultLimit int) (ScoreOptions, error) {
+	var options ScoreOptions
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &options); err != nil {
+			return ScoreOptions{}, fmt.Errorf("invalid score options: %w", err)
+		}
+	}
+
+	if options.Limit <= 0 {
+		options.Limit = defaultLimit
+	}
+
+	return options, nil
+}
+
+func (o ScoreOptions) accepts(item model.Item) bool {
+	return item.Score >= o.MinScore && item.Comments >= o.MinComments
+}
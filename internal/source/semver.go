@@ -0,0 +1,161 @@
+package source
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version. Build metadata is ignored.
+type semver struct {
+	major, minor, patch int
+	prerelease          []string
+}
+
+// parseSemver accepts versions with an optional "v" prefix and missing
+// minor or patch parts, as found in the tags of many repositories.
+func parseSemver(value string) (semver, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	value, _, _ = strings.Cut(value, "+")
+
+	core, prerelease, hasPrerelease := strings.Cut(value, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 || parts[0] == "" {
+		return semver{}, fmt.Errorf("invalid version %q", value)
+	}
+
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, fmt.Errorf("invalid version %q", value)
+		}
+		numbers[i] = n
+	}
+
+	v := semver{major: numbers[0], minor: numbers[1], patch: numbers[2]}
+	if hasPrerelease {
+		v.prerelease = strings.Split(prerelease, ".")
+	}
+
+	return v, nil
+}
+
+func (v semver) compare(other semver) int {
+	for _, diff := range []int{v.major - other.major, v.minor - other.minor, v.patch - other.patch} {
+		if diff != 0 {
+			return sign(diff)
+		}
+	}
+
+	// A version without a prerelease has a higher precedence than one with it.
+	switch {
+	case len(v.prerelease) == 0 && len(other.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(other.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.prerelease) && i < len(other.prerelease); i++ {
+		a, b := v.prerelease[i], other.prerelease[i]
+		if a == b {
+			continue
+		}
+
+		an, aErr := strconv.Atoi(a)
+		bn, bErr := strconv.Atoi(b)
+		switch {
+		case aErr == nil && bErr == nil:
+			return sign(an - bn)
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			return strings.Compare(a, b)
+		}
+	}
+
+	return sign(len(v.prerelease) - len(other.prerelease))
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+type semverComparator struct {
+	op      string
+	version semver
+}
+
+// semverRange is a set of comparators that must all match, e.g. ">=1.2.0 <2.0.0".
+// The caret and tilde shorthands "^1.2.3" and "~1.2.3" are expanded to such pairs.
+type semverRange []semverComparator
+
+func parseSemverRange(value string) (semverRange, error) {
+	var result semverRange
+
+	for _, field := range strings.Fields(value) {
+		op := strings.TrimRightFunc(field, func(r rune) bool {
+			return r != '<' && r != '>' && r != '=' && r != '^' && r != '~'
+		})
+		version, err := parseSemver(field[len(op):])
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case "^":
+			upper := semver{major: version.major + 1}
+			if version.major == 0 {
+				upper = semver{minor: version.minor + 1}
+			}
+			result = append(result, semverComparator{">=", version}, semverComparator{"<", upper})
+		case "~":
+			upper := semver{major: version.major, minor: version.minor + 1}
+			result = append(result, semverComparator{">=", version}, semverComparator{"<", upper})
+		case "", "=", ">", ">=", "<", "<=":
+			result = append(result, semverComparator{op, version})
+		default:
+			return nil, fmt.Errorf("invalid version constraint %q", field)
+		}
+	}
+
+	return result, nil
+}
+
+func (r semverRange) contains(v semver) bool {
+	for _, c := range r {
+		cmp := v.compare(c.version)
+
+		var ok bool
+		switch c.op {
+		case "", "=":
+			ok = cmp == 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
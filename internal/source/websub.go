@@ -0,0 +1,92 @@
+package source
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+)
+
+// WebSubLinks are the hub a feed publishes its updates through and the
+// canonical topic URL of the feed, see https://www.w3.org/TR/websub/#discovery.
+type WebSubLinks struct {
+	Hub  string
+	Self string
+}
+
+// webSubLinks looks for the hub and self links in the Link headers of the
+// response first and in the feed document after that. Only the feed level
+// links are considered, the document is not read past the first item.
+func webSubLinks(header http.Header, body []byte) WebSubLinks {
+	var links WebSubLinks
+
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			href, params, ok := strings.Cut(link, ";")
+			if !ok {
+				continue
+			}
+			href = strings.Trim(strings.TrimSpace(href), "<>")
+			rel := linkParam(params, "rel")
+			links.set(rel, href)
+		}
+	}
+	if links.Hub != "" {
+		return links
+	}
+
+	decoder := newXMLDecoder(body)
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return links
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "item", "entry":
+			return links
+		case "link":
+			var rel, href string
+			for _, attr := range start.Attr {
+				switch attr.Name.Local {
+				case "rel":
+					rel = attr.Value
+				case "href":
+					href = attr.Value
+				}
+			}
+			links.set(rel, strings.TrimSpace(href))
+		}
+	}
+}
+
+func (l *WebSubLinks) set(rel string, href string) {
+	if href == "" {
+		return
+	}
+
+	for _, field := range strings.Fields(strings.ToLower(rel)) {
+		switch {
+		case field == "hub" && l.Hub == "":
+			l.Hub = href
+		case field == "self" && l.Self == "":
+			l.Self = href
+		}
+	}
+}
+
+// linkParam returns the value of a parameter of a Link header entry.
+func linkParam(params string, name string) string {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+package source
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+
+	"golang.org/x/net/html/charset"
+)
+
+// newXMLDecoder returns a lenient decoder for the feeds found in the wild.
+// Documents declared in a legacy encoding (ISO-8859-1, windows-1251, ...)
+// are converted to UTF-8, an unknown encoding is read as it is.
+func newXMLDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		converted, err := charset.NewReaderLabel(label, input)
+		if err != nil {
+			return input, nil
+		}
+		return converted, nil
+	}
+
+	return decoder
+}
@@ -0,0 +1,40 @@
+package source
+
+import (
+	"testing"
+)
+
+func TestParseAtomLegacyCharset(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		title string
+	}{
+		{
+			name: "iso-8859-1",
+			data: []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+				"<feed xmlns=\"http://www.w3.org/2005/Atom\"><title>Caf\xe9</title>" +
+				"<entry><title>Cr\xe8me br\xfbl\xe9e</title><link href=\"https://example.com/1\"/></entry></feed>"),
+			title: "Crème brûlée",
+		},
+		{
+			name: "windows-1251",
+			data: []byte("<?xml version=\"1.0\" encoding=\"windows-1251\"?>\n" +
+				"<feed xmlns=\"http://www.w3.org/2005/Atom\"><title>\xcd\xee\xe2\xee\xf1\xf2\xe8</title>" +
+				"<entry><title>\xcf\xf0\xe8\xe2\xe5\xf2</title><link href=\"https://example.com/1\"/></entry></feed>"),
+			title: "Привет",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := ParseAtom(tt.data)
+			if err != nil {
+				t.Fatalf("ParseAtom() error = %v", err)
+			}
+			if len(items) != 1 || items[0].Title != tt.title {
+				t.Fatalf("ParseAtom() items = %+v, want the title %q", items, tt.title)
+			}
+		})
+	}
+}
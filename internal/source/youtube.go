@@ -0,0 +1,177 @@
+package source
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const youTubeNamespace = "http://www.youtube.com/xml/schemas/2015"
+
+type youTubeFeed struct {
+	XMLName xml.Name       `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string         `xml:"title"`
+	Entries []youTubeEntry `xml:"entry"`
+}
+
+type youTubeEntry struct {
+	VideoID   string       `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+	Title     string       `xml:"title"`
+	Links     []atomLink   `xml:"link"`
+	Published string       `xml:"published"`
+	Updated   string       `xml:"updated"`
+	Authors   []atomPerson `xml:"author"`
+	Group     struct {
+		Description string             `xml:"http://search.yahoo.com/mrss/ description"`
+		Content     mediaContent       `xml:"http://search.yahoo.com/mrss/ content"`
+		Thumbnails  []youTubeThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+type youTubeThumbnail struct {
+	URL   string `xml:"url,attr"`
+	Width int    `xml:"width,attr"`
+}
+
+// YouTubeSource reads the Atom feed of a YouTube channel or playlist.
+// The feeds carry no video duration, so it is only known when the
+// media:content element has one.
+type YouTubeSource struct {
+	httpFeed
+
+	URL        string
+	SourceID   int64
+	SourceName string
+}
+
+func NewYouTubeSourceFromModel(m model.Source, client *http.Client) (YouTubeSource, error) {
+	feedURL, err := youTubeFeedURL(m.FeedURL)
+	if err != nil {
+		return YouTubeSource{}, err
+	}
+
+	return YouTubeSource{
+		httpFeed:   newHTTPFeed(client, Validators{ETag: m.ETag, LastModified: m.LastModified}),
+		URL:        feedURL,
+		SourceID:   m.ID,
+		SourceName: m.Name,
+	}, nil
+}
+
+// youTubeFeedURL accepts a channel or playlist page URL as well as the feed URL itself.
+// Channel handles (youtube.com/@name) have to be resolved through feed discovery.
+func youTubeFeedURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", err
+	}
+
+	host := strings.TrimPrefix(strings.TrimPrefix(u.Host, "www."), "m.")
+	if host != "youtube.com" {
+		return "", fmt.Errorf("%s is not a YouTube URL", raw)
+	}
+
+	feed := url.URL{Scheme: "https", Host: "www.youtube.com", Path: "/feeds/videos.xml"}
+	switch {
+	case u.Path == "/feeds/videos.xml":
+		return u.String(), nil
+	case strings.HasPrefix(u.Path, "/channel/"):
+		channelID, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/channel/"), "/")
+		feed.RawQuery = url.Values{"channel_id": {channelID}}.Encode()
+	case u.Path == "/playlist" && u.Query().Get("list") != "":
+		feed.RawQuery = url.Values{"playlist_id": {u.Query().Get("list")}}.Encode()
+	default:
+		return "", fmt.Errorf("%s is not a YouTube channel, playlist or feed URL, add it without a type to discover its feed", raw)
+	}
+
+	return feed.String(), nil
+}
+
+func (s YouTubeSource) Fetch(ctx context.Context) ([]model.Item, error) {
+	doc, err := s.fetch(ctx, s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	title, items, err := parseYouTube(doc.body)
+	if err != nil {
+		return nil, err
+	}
+	*s.title = title
+
+	for i := range items {
+		items[i].SourceName = s.SourceName
+	}
+
+	return items, nil
+}
+
+func (s YouTubeSource) ID() int64 {
+	return s.SourceID
+}
+
+func (s YouTubeSource) Name() string {
+	return s.SourceName
+}
+
+// ParseYouTube parses the Atom feed of a YouTube channel or playlist into items.
+func ParseYouTube(data []byte) ([]model.Item, error) {
+	_, items, err := parseYouTube(data)
+	return items, err
+}
+
+func parseYouTube(data []byte) (string, []model.Item, error) {
+	var feed youTubeFeed
+	if err := newXMLDecoder(data).Decode(&feed); err != nil {
+		return "", nil, err
+	}
+
+	items := make([]model.Item, 0, len(feed.Entries))
+	for _, entry := range feed.Entries {
+		item := model.Item{
+			Title:    strings.TrimSpace(entry.Title),
+			Summary:  strings.TrimSpace(entry.Group.Description),
+			Date:     parseRFC3339(entry.Published, entry.Updated),
+			Duration: parseMediaDuration(entry.Group.Content.Duration),
+		}
+
+		for _, link := range entry.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				item.Link = link.Href
+				break
+			}
+		}
+		if item.Link == "" && entry.VideoID != "" {
+			item.Link = "https://www.youtube.com/watch?v=" + url.QueryEscape(entry.VideoID)
+		}
+		if item.Link == "" {
+			continue
+		}
+
+		if len(entry.Authors) > 0 {
+			item.Author = entry.Authors[0].Name
+		}
+
+		item.Enclosures = []model.Enclosure{{URL: item.Link, Type: "video/*"}}
+
+		width := -1
+		for _, thumbnail := range entry.Group.Thumbnails {
+			if thumbnail.URL != "" && thumbnail.Width > width {
+				item.Thumbnail = thumbnail.URL
+				width = thumbnail.Width
+			}
+		}
+		if item.Thumbnail == "" && entry.VideoID != "" {
+			item.Thumbnail = "https://i.ytimg.com/vi/" + url.PathEscape(entry.VideoID) + "/hqdefault.jpg"
+		}
+
+		items = append(items, item)
+	}
+
+	return strings.TrimSpace(feed.Title), items, nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AdvisoryLock is a session level Postgres advisory lock. The session is a
+// connection of its own, so the lock is gone as soon as the connection is,
+// e.g. when the process that held it dies. It is not safe for concurrent use.
+type AdvisoryLock struct {
+	db   *sqlx.DB
+	key  int64
+	conn *sql.Conn
+}
+
+func NewAdvisoryLock(db *sqlx.DB, key int64) *AdvisoryLock {
+	return &AdvisoryLock{
+		db:  db,
+		key: key,
+	}
+}
+
+// TryAcquire takes the lock if no other session holds it.
+func (l *AdvisoryLock) TryAcquire(ctx context.Context) (bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&acquired); err != nil {
+		conn.Close()
+		return false, err
+	}
+
+	if !acquired {
+		return false, conn.Close()
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+// Check fails when the session of the lock is gone. The connection is never
+// replaced behind the scenes, so a working session still holds the lock.
+func (l *AdvisoryLock) Check(ctx context.Context) error {
+	if l.conn == nil {
+		return sql.ErrConnDone
+	}
+
+	_, err := l.conn.ExecContext(ctx, "SELECT 1")
+	return err
+}
+
+// Release unlocks and gives the session back. When the unlock fails the
+// session is closed instead, a pooled session would keep holding the lock.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+	defer func() {
+		l.conn.Close()
+		l.conn = nil
+	}()
+
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	if err != nil {
+		// A bad connection is closed rather than put back into the pool.
+		_ = l.conn.Raw(func(any) error {
+			return driver.ErrBadConn
+		})
+	}
+
+	return err
+}
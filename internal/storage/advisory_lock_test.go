@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// lockDriver is a database driver whose sessions always get the advisory
+// lock and fail to give it back when unlockErr is set.
+type lockDriver struct {
+	unlockErr error
+	closed    atomic.Int32
+}
+
+func (d *lockDriver) Connect(context.Context) (driver.Conn, error) { return &lockConn{driver: d}, nil }
+func (d *lockDriver) Driver() driver.Driver                        { return nil }
+
+type lockConn struct {
+	driver *lockDriver
+}
+
+func (c *lockConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *lockConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c *lockConn) Close() error {
+	c.driver.closed.Add(1)
+	return nil
+}
+
+func (c *lockConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &boolRows{value: true}, nil
+}
+
+func (c *lockConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	if c.driver.unlockErr != nil {
+		return nil, c.driver.unlockErr
+	}
+	return driver.RowsAffected(0), nil
+}
+
+type boolRows struct {
+	value bool
+	done  bool
+}
+
+func (r *boolRows) Columns() []string { return []string{"locked"} }
+func (r *boolRows) Close() error      { return nil }
+
+func (r *boolRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
+
+func TestAdvisoryLockRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		unlockErr  error
+		wantClosed int32
+		wantIdle   int
+	}{
+		{name: "unlocked session goes back to the pool", wantClosed: 0, wantIdle: 1},
+		{name: "failed unlock discards the session", unlockErr: errors.New("connection reset"), wantClosed: 1, wantIdle: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &lockDriver{unlockErr: tt.unlockErr}
+			db := sqlx.NewDb(sql.OpenDB(d), "postgres")
+			t.Cleanup(func() { _ = db.Close() })
+
+			lock := NewAdvisoryLock(db, 42)
+			acquired, err := lock.TryAcquire(context.Background())
+			if err != nil || !acquired {
+				t.Fatalf("TryAcquire() = %v, %v, want the lock", acquired, err)
+			}
+
+			if err := lock.Release(context.Background()); !errors.Is(err, tt.unlockErr) {
+				t.Fatalf("Release() error = %v, want %v", err, tt.unlockErr)
+			}
+
+			if closed := d.closed.Load(); closed != tt.wantClosed {
+				t.Errorf("closed sessions = %d, want %d", closed, tt.wantClosed)
+			}
+			if idle := db.Stats().Idle; idle != tt.wantIdle {
+				t.Errorf("idle sessions = %d, want %d", idle, tt.wantIdle)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -11,14 +12,63 @@ import (
 )
 
 type dbArticle struct {
-	ID          int64     `db:"id"`
-	SourceID    int64     `db:"source_id"`
-	Title       string    `db:"title"`
-	Link        string    `db:"link"`
-	Summary     string    `db:"summary"`
-	PublishedAt time.Time `db:"published_at"`
-	PostedAt    time.Time `db:"posted_at"`
-	CreatedAt   time.Time `db:"created_at"`
+	ID             int64         `db:"id"`
+	SourceID       int64         `db:"source_id"`
+	SourceName     string        `db:"source_name"`
+	SourcePriority int           `db:"source_priority"`
+	Title          string        `db:"title"`
+	Link           string        `db:"link"`
+	CanonicalLink  string        `db:"canonical_link"`
+	Summary        string        `db:"summary"`
+	EnclosureURL   string        `db:"enclosure_url"`
+	Duration       int64         `db:"duration"`
+	ThumbnailURL   string        `db:"thumbnail_url"`
+	Language       string        `db:"language"`
+	TranslateTo    string        `db:"translate_to"`
+	PublishedAt    time.Time     `db:"published_at"`
+	PostedAt       sql.NullTime  `db:"posted_at"`
+	CreatedAt      time.Time     `db:"created_at"`
+	ClusterID      sql.NullInt64 `db:"cluster_id"`
+	State          string        `db:"state"`
+	Attempts       int           `db:"attempts"`
+	LastError      string        `db:"last_error"`
+}
+
+func (a dbArticle) toModel() model.Article {
+	return model.Article{
+		ID:             a.ID,
+		SourceID:       a.SourceID,
+		SourceName:     a.SourceName,
+		SourcePriority: a.SourcePriority,
+		Title:          a.Title,
+		Link:           a.Link,
+		CanonicalLink:  a.CanonicalLink,
+		Summary:        a.Summary,
+		EnclosureURL:   a.EnclosureURL,
+		Duration:       time.Duration(a.Duration) * time.Second,
+		Thumbnail:      a.ThumbnailURL,
+		Language:       a.Language,
+		TranslateTo:    a.TranslateTo,
+		PublishedAt:    a.PublishedAt,
+		PostedAt:       a.PostedAt.Time,
+		CreatedAt:      a.CreatedAt,
+		ClusterID:      a.ClusterID.Int64,
+		State:          a.State,
+		Attempts:       a.Attempts,
+		LastError:      a.LastError,
+	}
+}
+
+// claimableCondition selects the articles the notifier may claim at the time
+// of the now parameter: the new ones, the claimed ones whose claimer did not
+// finish in time and the summarized ones whose sending failed, once their
+// retry time has come.
+func claimableCondition(now string) string {
+	return fmt.Sprintf(`(
+		(a.state = 'new' AND (a.retry_at IS NULL OR a.retry_at <= %[1]s::timestamp))
+		OR (a.state = 'claimed' AND a.claimed_until < %[1]s::timestamp)
+		OR (a.state = 'summarized' AND a.claimed_until IS NULL AND (a.retry_at IS NULL OR a.retry_at <= %[1]s::timestamp))
+	)`, now)
 }
 
 type ArticlePostgresStorage struct {
@@ -38,32 +88,53 @@ func (s *ArticlePostgresStorage) AddArticle(ctx context.Context, article model.A
 	}
 	defer conn.Close()
 
+	canonicalLink := article.CanonicalLink
+	if canonicalLink == "" {
+		canonicalLink = article.Link
+	}
+
 	row := conn.QueryRowContext(
 		ctx,
 		`
-			INSERT INTO articles (source_id, title, link, summary, published_at)
-			VALUES ($1, $2, $3, $4, $5)
+			INSERT INTO articles (
+				source_id, title, link, canonical_link, summary, enclosure_url, duration, thumbnail_url,
+				language, translate_to, published_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 			ON CONFLICT DO NOTHING
 			RETURNING id
 		`,
 		article.SourceID,
 		article.Title,
 		article.Link,
+		canonicalLink,
 		article.Summary,
+		article.EnclosureURL,
+		int64(article.Duration/time.Second),
+		article.Thumbnail,
+		article.Language,
+		article.TranslateTo,
 		article.PublishedAt,
 	)
 	if err := row.Err(); err != nil {
 		return 0, err
 	}
 
+	// ON CONFLICT DO NOTHING returns no rows for an article that is already stored.
 	var id int64
 	if err := row.Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
 		return 0, err
 	}
 
 	return id, nil
 }
 
+// AllNotPosted returns the articles published since the given time that the
+// notifier may claim now. The articles waiting for a retry are returned however
+// old they are, the retry delay outgrows the publish window after a few attempts.
 func (s *ArticlePostgresStorage) AllNotPosted(ctx context.Context, since time.Time, limit uint64) ([]model.Article, error) {
 	conn, err := s.db.Conn(ctx)
 	if err != nil {
@@ -71,40 +142,365 @@ func (s *ArticlePostgresStorage) AllNotPosted(ctx context.Context, since time.Ti
 	}
 	defer conn.Close()
 
-	var articles []dbArticle
 	rows, err := conn.QueryContext(
 		ctx,
 		`
-			SELECT * FROM articles
-			WHERE posted_at IS NULL AND published_at >= $1::timestamp
-			ORDER BY published_at DESC
+			SELECT `+articleColumns+`
+			FROM articles a
+			JOIN sources s ON s.id = a.source_id
+			WHERE `+claimableCondition("$3")+` AND (a.published_at >= $1::timestamp OR a.retry_at IS NOT NULL)
+				AND NOT EXISTS (
+					SELECT 1 FROM articles p
+					WHERE p.cluster_id = a.cluster_id AND p.posted_at IS NOT NULL
+				)
+			ORDER BY a.published_at DESC
 			LIMIT $2
 		`,
 		since.UTC().Format(time.RFC3339),
 		limit,
+		time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanArticles(rows)
+}
+
+// FailedArticles returns the articles that the notifier gave up on, the newest articles first.
+func (s *ArticlePostgresStorage) FailedArticles(ctx context.Context, limit int) ([]model.Article, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`
+			SELECT `+articleColumns+`
+			FROM articles a
+			JOIN sources s ON s.id = a.source_id
+			WHERE a.state = $1
+			ORDER BY a.published_at DESC
+			LIMIT $2
+		`,
+		model.ArticleStateFailed,
+		limit,
 	)
 	if err != nil {
 		return nil, err
 	}
+
+	return scanArticles(rows)
+}
+
+// articleColumns are the columns read by scanArticles, the article is "a" and its source is "s".
+const articleColumns = `
+	a.id, a.source_id, s.name, COALESCE(s.priority, 0), a.title, a.link, a.canonical_link, a.summary,
+	a.enclosure_url, a.duration, a.thumbnail_url, a.language, a.translate_to,
+	a.published_at, a.posted_at, a.created_at, a.cluster_id, a.state, a.attempts, a.last_error`
+
+func scanArticles(rows *sql.Rows) ([]model.Article, error) {
 	defer rows.Close()
 
+	var result []model.Article
 	for rows.Next() {
-		var src dbArticle
-		if err := rows.Scan(&src.ID, &src.SourceID, &src.Title, &src.Link, &src.Summary, &src.PublishedAt, &src.PostedAt, &src.CreatedAt); err != nil {
+		src, err := scanArticle(rows)
+		if err != nil {
 			return nil, err
 		}
-		articles = append(articles, src)
+		result = append(result, src.toModel())
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	result := make([]model.Article, len(articles))
-	for i, src := range articles {
-		result[i] = model.Article(src)
+	return result, nil
+}
+
+func scanArticle(row interface{ Scan(dest ...any) error }) (dbArticle, error) {
+	var src dbArticle
+	err := row.Scan(
+		&src.ID, &src.SourceID, &src.SourceName, &src.SourcePriority, &src.Title, &src.Link, &src.CanonicalLink, &src.Summary,
+		&src.EnclosureURL, &src.Duration, &src.ThumbnailURL, &src.Language, &src.TranslateTo,
+		&src.PublishedAt, &src.PostedAt, &src.CreatedAt, &src.ClusterID, &src.State, &src.Attempts, &src.LastError,
+	)
+
+	return src, err
+}
+
+// PostedCountBySource returns how many articles of each source were posted since the given time.
+func (s *ArticlePostgresStorage) PostedCountBySource(ctx context.Context, since time.Time) (map[int64]int, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer conn.Close()
 
-	return result, nil
+	rows, err := conn.QueryContext(
+		ctx,
+		`
+			SELECT source_id, COUNT(*) FROM articles
+			WHERE posted_at >= $1::timestamp
+			GROUP BY source_id
+		`,
+		since.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int64]int)
+	for rows.Next() {
+		var (
+			sourceID int64
+			count    int
+		)
+		if err := rows.Scan(&sourceID, &count); err != nil {
+			return nil, err
+		}
+		counts[sourceID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// RecentFingerprints returns the SimHash, the source and the story cluster of the articles stored since the given time.
+func (s *ArticlePostgresStorage) RecentFingerprints(ctx context.Context, since time.Time) ([]model.Fingerprint, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`
+			SELECT id, source_id, cluster_id, simhash FROM articles
+			WHERE created_at >= $1::timestamp AND simhash IS NOT NULL AND cluster_id IS NOT NULL
+		`,
+		since.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fingerprints []model.Fingerprint
+	for rows.Next() {
+		var (
+			fingerprint model.Fingerprint
+			simHash     int64
+		)
+		if err := rows.Scan(&fingerprint.ArticleID, &fingerprint.SourceID, &fingerprint.ClusterID, &simHash); err != nil {
+			return nil, err
+		}
+		// The hash is stored in a signed BIGINT column.
+		fingerprint.SimHash = uint64(simHash)
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return fingerprints, nil
+}
+
+// AssignCluster stores the SimHash of the article and puts it into the story cluster.
+// A clusterID of 0 opens a new cluster. It returns the id of the cluster.
+func (s *ArticlePostgresStorage) AssignCluster(ctx context.Context, articleID int64, simHash uint64, clusterID int64) (int64, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if clusterID == 0 {
+		if err := tx.QueryRowContext(ctx, "INSERT INTO story_clusters DEFAULT VALUES RETURNING id").Scan(&clusterID); err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE articles SET simhash = $1, cluster_id = $2 WHERE id = $3",
+		int64(simHash),
+		clusterID,
+		articleID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return clusterID, nil
+}
+
+// ClaimArticle claims the article for the notifier until the given time, unless
+// it is not claimable anymore or another transaction holds it. It returns the
+// summary of an article that was summarized by an earlier attempt.
+func (s *ArticlePostgresStorage) ClaimArticle(ctx context.Context, id int64, until time.Time) (bool, string, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, "", err
+	}
+	defer tx.Rollback()
+
+	var state, summary string
+	err = tx.QueryRowContext(
+		ctx,
+		`
+			SELECT a.state, a.post_summary
+			FROM articles a
+			WHERE a.id = $1 AND `+claimableCondition("$2")+`
+			FOR UPDATE SKIP LOCKED
+		`,
+		id,
+		time.Now().UTC().Format(time.RFC3339),
+	).Scan(&state, &summary)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, "", nil
+		}
+		return false, "", err
+	}
+
+	// A summarized article keeps its state, the claim is the claimed_until alone.
+	if state != model.ArticleStateSummarized {
+		state = model.ArticleStateClaimed
+		summary = ""
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE articles SET state = $1, claimed_until = $2::timestamp WHERE id = $3",
+		state,
+		until.UTC().Format(time.RFC3339),
+		id,
+	)
+	if err != nil {
+		return false, "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, "", err
+	}
+
+	return true, summary, nil
+}
+
+// MarkSummarized stores the summary of the claimed article, a retry of the
+// sending does not summarize the article again.
+func (s *ArticlePostgresStorage) MarkSummarized(ctx context.Context, id int64, summary string) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(
+		ctx,
+		"UPDATE articles SET state = $1, post_summary = $2 WHERE id = $3",
+		model.ArticleStateSummarized,
+		summary,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RecordPostFailure releases the claim after a failed attempt. The article is
+// claimable again at retryAt, or failed once it had maxAttempts attempts.
+// It returns the new state of the article.
+func (s *ArticlePostgresStorage) RecordPostFailure(ctx context.Context, id int64, lastError string, retryAt time.Time, maxAttempts int) (string, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	var state string
+	err = conn.QueryRowContext(
+		ctx,
+		`
+			UPDATE articles SET
+				attempts = attempts + 1,
+				last_error = $2,
+				retry_at = $3::timestamp,
+				claimed_until = NULL,
+				state = CASE
+					WHEN attempts + 1 >= $4 THEN $5
+					WHEN state = $6 THEN $7
+					ELSE state
+				END
+			WHERE id = $1
+			RETURNING state
+		`,
+		id,
+		lastError,
+		retryAt.UTC().Format(time.RFC3339),
+		maxAttempts,
+		model.ArticleStateFailed,
+		model.ArticleStateClaimed,
+		model.ArticleStateNew,
+	).Scan(&state)
+	if err != nil {
+		return "", err
+	}
+
+	return state, nil
+}
+
+// FailInterruptedPosts fails the summarized and the posting articles whose
+// claim ran out. The notifier stopped while sending them, so they may or may
+// not have been posted and are not sent again on their own.
+func (s *ArticlePostgresStorage) FailInterruptedPosts(ctx context.Context, now time.Time) (int64, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(
+		ctx,
+		`
+			UPDATE articles SET state = $1, claimed_until = NULL, last_error = $2
+			WHERE state IN ($3, $4) AND claimed_until < $5::timestamp
+		`,
+		model.ArticleStateFailed,
+		"interrupted while sending, the article may already be in the channel",
+		model.ArticleStateSummarized,
+		model.ArticleStatePosting,
+		now.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
 }
 
 func (s *ArticlePostgresStorage) MarkPosted(ctx context.Context, id int64) error {
@@ -116,17 +512,138 @@ func (s *ArticlePostgresStorage) MarkPosted(ctx context.Context, id int64) error
 
 	_, err = conn.ExecContext(
 		ctx,
-		"UPDATE articles SET posted_at = $1::timestamp WHERE id = $1",
+		`
+			UPDATE articles SET posted_at = $1::timestamp, state = $2, claimed_until = NULL, last_error = ''
+			WHERE id = $3
+		`,
+		time.Now().UTC().Format(time.RFC3339),
+		model.ArticleStateSent,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RetryFailedArticle gives the failed article a fresh set of attempts. An
+// article that has a summary already goes on with the sending. The retry is
+// due at once, which keeps the article eligible however long ago it was
+// published. It reports whether the article was failed.
+func (s *ArticlePostgresStorage) RetryFailedArticle(ctx context.Context, id int64) (bool, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(
+		ctx,
+		`
+			UPDATE articles SET
+				state = CASE WHEN post_summary <> '' THEN $1 ELSE $2 END,
+				attempts = 0,
+				retry_at = $5::timestamp,
+				claimed_until = NULL
+			WHERE id = $3 AND state = $4
+		`,
+		model.ArticleStateSummarized,
+		model.ArticleStateNew,
 		id,
+		model.ArticleStateFailed,
 		time.Now().UTC().Format(time.RFC3339),
 	)
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected(res)
+}
+
+// SkipFailedArticle drops the failed article from the dead-letter queue for good.
+// It reports whether the article was failed.
+func (s *ArticlePostgresStorage) SkipFailedArticle(ctx context.Context, id int64) (bool, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	res, err := conn.ExecContext(
+		ctx,
+		"UPDATE articles SET state = $1 WHERE id = $2 AND state = $3",
+		model.ArticleStateSkipped,
+		id,
+		model.ArticleStateFailed,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected(res)
+}
+
+// ClaimFailedArticle claims the failed article until the given time, so that
+// it can be posted by hand. The article is posting rather than claimed, so
+// that the notifier does not take it over with a summary when the claim runs
+// out. It reports false when the article is not failed or is being handled
+// already.
+func (s *ArticlePostgresStorage) ClaimFailedArticle(ctx context.Context, id int64, until time.Time) (model.Article, bool, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return model.Article{}, false, err
+	}
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return model.Article{}, false, err
+	}
+	defer tx.Rollback()
+
+	article, err := scanArticle(tx.QueryRowContext(
+		ctx,
+		`
+			SELECT `+articleColumns+`
+			FROM articles a
+			JOIN sources s ON s.id = a.source_id
+			WHERE a.id = $1 AND a.state = $2
+			FOR UPDATE OF a SKIP LOCKED
+		`,
+		id,
+		model.ArticleStateFailed,
+	))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil
+			return model.Article{}, false, nil
 		}
+		return model.Article{}, false, err
+	}
 
-		return err
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE articles SET state = $1, claimed_until = $2::timestamp WHERE id = $3",
+		model.ArticleStatePosting,
+		until.UTC().Format(time.RFC3339),
+		id,
+	)
+	if err != nil {
+		return model.Article{}, false, err
 	}
 
-	return nil
+	if err := tx.Commit(); err != nil {
+		return model.Article{}, false, err
+	}
+
+	return article.toModel(), true, nil
+}
+
+func rowsAffected(res sql.Result) (bool, error) {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
 }
@@ -0,0 +1,14 @@
+package storage
+
+import "errors"
+
+// uniqueViolation is the SQLSTATE of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// isUniqueViolation reports whether the error is a unique constraint violation.
+// The check goes through the SQLState method that both lib/pq and pgx errors
+// have, so that it does not depend on the driver.
+func isUniqueViolation(err error) bool {
+	var stateErr interface{ SQLState() string }
+	return errors.As(err, &stateErr) && stateErr.SQLState() == uniqueViolation
+}
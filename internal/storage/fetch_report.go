@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+type dbFetchReport struct {
+	ID               int64     `db:"id"`
+	StartedAt        time.Time `db:"started_at"`
+	DurationMS       int64     `db:"duration_ms"`
+	SourcesAttempted int       `db:"sources_attempted"`
+	SourcesSucceeded int       `db:"sources_succeeded"`
+	SourcesFailed    int       `db:"sources_failed"`
+	ItemsSeen        int       `db:"items_seen"`
+	ItemsInserted    int       `db:"items_inserted"`
+	ItemsDuplicate   int       `db:"items_duplicate"`
+	ItemsFiltered    int       `db:"items_filtered"`
+	Errors           []byte    `db:"errors"`
+}
+
+func (r dbFetchReport) toModel() (model.FetchReport, error) {
+	report := model.FetchReport{
+		ID:               r.ID,
+		StartedAt:        r.StartedAt,
+		Duration:         time.Duration(r.DurationMS) * time.Millisecond,
+		SourcesAttempted: r.SourcesAttempted,
+		SourcesSucceeded: r.SourcesSucceeded,
+		SourcesFailed:    r.SourcesFailed,
+		ItemsSeen:        r.ItemsSeen,
+		ItemsInserted:    r.ItemsInserted,
+		ItemsDuplicate:   r.ItemsDuplicate,
+		ItemsFiltered:    r.ItemsFiltered,
+	}
+
+	if err := json.Unmarshal(r.Errors, &report.Errors); err != nil {
+		return model.FetchReport{}, err
+	}
+
+	return report, nil
+}
+
+func (s *FetchRunPostgresStorage) AddFetchReport(ctx context.Context, report model.FetchReport) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	errorCounts, err := json.Marshal(report.Errors)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(
+		ctx,
+		`
+			INSERT INTO fetch_reports (
+				started_at, duration_ms, sources_attempted, sources_succeeded, sources_failed,
+				items_seen, items_inserted, items_duplicate, items_filtered, errors
+			)
+			VALUES ($1::timestamp, $2, $3, $4, $5, $6, $7, $8, $9, $10::jsonb)
+		`,
+		report.StartedAt.UTC().Format(time.RFC3339),
+		report.Duration.Milliseconds(),
+		report.SourcesAttempted,
+		report.SourcesSucceeded,
+		report.SourcesFailed,
+		report.ItemsSeen,
+		report.ItemsInserted,
+		report.ItemsDuplicate,
+		report.ItemsFiltered,
+		string(errorCounts),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetFetchReports returns the latest reports, newest first.
+func (s *FetchRunPostgresStorage) GetFetchReports(ctx context.Context, limit int) ([]model.FetchReport, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`
+			SELECT
+				id, started_at, duration_ms, sources_attempted, sources_succeeded, sources_failed,
+				items_seen, items_inserted, items_duplicate, items_filtered, errors
+			FROM fetch_reports
+			ORDER BY started_at DESC
+			LIMIT $1
+		`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []model.FetchReport
+	for rows.Next() {
+		var r dbFetchReport
+		if err := rows.Scan(
+			&r.ID, &r.StartedAt, &r.DurationMS, &r.SourcesAttempted, &r.SourcesSucceeded, &r.SourcesFailed,
+			&r.ItemsSeen, &r.ItemsInserted, &r.ItemsDuplicate, &r.ItemsFiltered, &r.Errors,
+		); err != nil {
+			return nil, err
+		}
+
+		report, err := r.toModel()
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+type dbFetchRun struct {
+	ID           int64     `db:"id"`
+	SourceID     int64     `db:"source_id"`
+	Status       string    `db:"status"`
+	HTTPStatus   int       `db:"http_status"`
+	ItemCount    int       `db:"item_count"`
+	NewItemCount int       `db:"new_item_count"`
+	DurationMS   int64     `db:"duration_ms"`
+	Error        string    `db:"error"`
+	ErrorClass   string    `db:"error_class"`
+	StartedAt    time.Time `db:"started_at"`
+}
+
+func (r dbFetchRun) toModel() model.FetchRun {
+	return model.FetchRun{
+		ID:           r.ID,
+		SourceID:     r.SourceID,
+		Status:       r.Status,
+		HTTPStatus:   r.HTTPStatus,
+		ItemCount:    r.ItemCount,
+		NewItemCount: r.NewItemCount,
+		Duration:     time.Duration(r.DurationMS) * time.Millisecond,
+		Error:        r.Error,
+		ErrorClass:   r.ErrorClass,
+		StartedAt:    r.StartedAt,
+	}
+}
+
+type FetchRunPostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewFetchRunPostgresStorage(db *sqlx.DB) *FetchRunPostgresStorage {
+	return &FetchRunPostgresStorage{
+		db: db,
+	}
+}
+
+func (s *FetchRunPostgresStorage) AddFetchRun(ctx context.Context, run model.FetchRun) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(
+		ctx,
+		`
+			INSERT INTO fetch_runs (source_id, status, http_status, item_count, new_item_count, duration_ms, error, error_class, started_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::timestamp)
+		`,
+		run.SourceID,
+		run.Status,
+		run.HTTPStatus,
+		run.ItemCount,
+		run.NewItemCount,
+		run.Duration.Milliseconds(),
+		run.Error,
+		run.ErrorClass,
+		run.StartedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetSourcesHealth returns every source with its latest fetch run and
+// the number of total and failed runs since the given time.
+func (s *FetchRunPostgresStorage) GetSourcesHealth(ctx context.Context, since time.Time) ([]model.SourceHealth, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`
+			SELECT
+				`+prefixColumns("s", sourceColumns)+`,
+				r.id, r.status, r.http_status, r.item_count, r.new_item_count, r.duration_ms, r.error, r.error_class, r.started_at,
+				COALESCE(stats.total, 0), COALESCE(stats.failed, 0)
+			FROM sources s
+			LEFT JOIN LATERAL (
+				SELECT * FROM fetch_runs WHERE source_id = s.id ORDER BY started_at DESC LIMIT 1
+			) r ON TRUE
+			LEFT JOIN (
+				SELECT
+					source_id,
+					COUNT(*) AS total,
+					COUNT(*) FILTER (WHERE status = $2) AS failed
+				FROM fetch_runs
+				WHERE started_at >= $1::timestamp
+				GROUP BY source_id
+			) stats ON stats.source_id = s.id
+			ORDER BY s.enabled, COALESCE(stats.failed, 0) DESC, s.id
+		`,
+		since.UTC().Format(time.RFC3339),
+		model.FetchStatusError,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.SourceHealth
+	for rows.Next() {
+		var (
+			run struct {
+				ID           sql.NullInt64
+				Status       sql.NullString
+				HTTPStatus   sql.NullInt64
+				ItemCount    sql.NullInt64
+				NewItemCount sql.NullInt64
+				DurationMS   sql.NullInt64
+				Error        sql.NullString
+				ErrorClass   sql.NullString
+				StartedAt    sql.NullTime
+			}
+			health model.SourceHealth
+		)
+
+		src, err := scanSource(
+			rows,
+			&run.ID, &run.Status, &run.HTTPStatus, &run.ItemCount, &run.NewItemCount, &run.DurationMS, &run.Error, &run.ErrorClass, &run.StartedAt,
+			&health.RunsTotal, &health.RunsFailed,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		health.Source = src.toModel()
+		if run.ID.Valid {
+			lastRun := dbFetchRun{
+				ID:           run.ID.Int64,
+				SourceID:     src.ID,
+				Status:       run.Status.String,
+				HTTPStatus:   int(run.HTTPStatus.Int64),
+				ItemCount:    int(run.ItemCount.Int64),
+				NewItemCount: int(run.NewItemCount.Int64),
+				DurationMS:   run.DurationMS.Int64,
+				Error:        run.Error.String,
+				ErrorClass:   run.ErrorClass.String,
+				StartedAt:    run.StartedAt.Time,
+			}.toModel()
+			health.LastRun = &lastRun
+		}
+
+		result = append(result, health)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+type dbFilterRule struct {
+	ID        int64         `db:"id"`
+	SourceID  sql.NullInt64 `db:"source_id"`
+	Action    string        `db:"action"`
+	Condition []byte        `db:"condition"`
+	CreatedAt time.Time     `db:"created_at"`
+}
+
+func (r dbFilterRule) toModel() model.FilterRule {
+	return model.FilterRule{
+		ID:        r.ID,
+		SourceID:  r.SourceID.Int64,
+		Action:    r.Action,
+		Condition: r.Condition,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
+type FilterRulePostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewFilterRulePostgresStorage(db *sqlx.DB) *FilterRulePostgresStorage {
+	return &FilterRulePostgresStorage{
+		db: db,
+	}
+}
+
+func (s *FilterRulePostgresStorage) GetFilterRules(ctx context.Context) ([]model.FilterRule, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`
+			SELECT id, source_id, action, condition, created_at
+			FROM filter_rules
+			ORDER BY id
+		`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []model.FilterRule
+	for rows.Next() {
+		var rule dbFilterRule
+		if err := rows.Scan(&rule.ID, &rule.SourceID, &rule.Action, &rule.Condition, &rule.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule.toModel())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// AddFilterRule stores the rule and returns its id. A SourceID of 0 stores a global rule.
+func (s *FilterRulePostgresStorage) AddFilterRule(ctx context.Context, rule model.FilterRule) (int64, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	sourceID := sql.NullInt64{Int64: rule.SourceID, Valid: rule.SourceID != 0}
+
+	var id int64
+	err = conn.QueryRowContext(
+		ctx,
+		`
+			INSERT INTO filter_rules (source_id, action, condition)
+			VALUES ($1, $2, $3::jsonb)
+			RETURNING id
+		`,
+		sourceID,
+		rule.Action,
+		string(rule.Condition),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
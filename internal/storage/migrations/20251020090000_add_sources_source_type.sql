@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sources ADD COLUMN source_type VARCHAR(32) NOT NULL DEFAULT 'rss';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sources DROP COLUMN IF EXISTS source_type;
+-- +goose StatementEnd
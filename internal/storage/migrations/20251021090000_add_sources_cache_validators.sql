@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sources
+    ADD COLUMN etag TEXT NOT NULL DEFAULT '',
+    ADD COLUMN last_modified TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sources
+    DROP COLUMN IF EXISTS etag,
+    DROP COLUMN IF EXISTS last_modified;
+-- +goose StatementEnd
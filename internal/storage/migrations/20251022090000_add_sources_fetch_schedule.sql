@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sources
+    ADD COLUMN fetch_interval INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN next_fetch_at TIMESTAMP DEFAULT NULL,
+    ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN sources.fetch_interval IS 'Fetch interval in seconds, 0 means the global FETCH_INTERVAL';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sources
+    DROP COLUMN IF EXISTS fetch_interval,
+    DROP COLUMN IF EXISTS next_fetch_at,
+    DROP COLUMN IF EXISTS consecutive_failures,
+    DROP COLUMN IF EXISTS last_error;
+-- +goose StatementEnd
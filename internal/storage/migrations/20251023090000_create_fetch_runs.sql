@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE fetch_runs (
+    id SERIAL PRIMARY KEY,
+    source_id INTEGER NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    status VARCHAR(32) NOT NULL,
+    http_status INTEGER NOT NULL DEFAULT 0,
+    item_count INTEGER NOT NULL DEFAULT 0,
+    new_item_count INTEGER NOT NULL DEFAULT 0,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX fetch_runs_source_id_started_at_idx ON fetch_runs (source_id, started_at DESC);
+
+ALTER TABLE sources
+    ADD COLUMN enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN disabled_reason TEXT NOT NULL DEFAULT '',
+    ADD COLUMN last_item_at TIMESTAMP DEFAULT NULL;
+
+-- The existing sources start idling from their latest article, or from now
+-- when they have none, rather than from an unknown creation time.
+UPDATE sources s SET last_item_at = COALESCE(
+    (SELECT MAX(a.created_at) FROM articles a WHERE a.source_id = s.id),
+    CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sources
+    DROP COLUMN IF EXISTS enabled,
+    DROP COLUMN IF EXISTS disabled_reason,
+    DROP COLUMN IF EXISTS last_item_at;
+
+DROP TABLE IF EXISTS fetch_runs;
+-- +goose StatementEnd
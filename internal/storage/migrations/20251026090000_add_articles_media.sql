@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE articles
+    ADD COLUMN enclosure_url TEXT NOT NULL DEFAULT '',
+    ADD COLUMN duration INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN thumbnail_url TEXT NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN articles.duration IS 'Media duration in seconds, 0 when unknown';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS enclosure_url,
+    DROP COLUMN IF EXISTS duration,
+    DROP COLUMN IF EXISTS thumbnail_url;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sources ADD COLUMN cursor TEXT NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN sources.cursor IS 'Position a paging source resumes from, e.g. the since_id of a Mastodon timeline';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sources DROP COLUMN IF EXISTS cursor;
+-- +goose StatementEnd
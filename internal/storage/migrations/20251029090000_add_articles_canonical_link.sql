@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE articles
+    ALTER COLUMN link TYPE TEXT,
+    ADD COLUMN canonical_link TEXT;
+
+UPDATE articles SET canonical_link = link;
+
+ALTER TABLE articles
+    ALTER COLUMN canonical_link SET NOT NULL,
+    ADD CONSTRAINT articles_canonical_link_key UNIQUE (canonical_link);
+
+COMMENT ON COLUMN articles.link IS 'Link as it came from the source';
+COMMENT ON COLUMN articles.canonical_link IS 'Normalized link the articles are deduplicated on';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS canonical_link,
+    ALTER COLUMN link TYPE VARCHAR(255);
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE story_clusters (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE articles
+    ADD COLUMN simhash BIGINT DEFAULT NULL,
+    ADD COLUMN cluster_id INTEGER DEFAULT NULL REFERENCES story_clusters(id) ON DELETE SET NULL;
+
+CREATE INDEX articles_cluster_id_idx ON articles (cluster_id);
+CREATE INDEX articles_created_at_idx ON articles (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS articles_created_at_idx;
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS cluster_id,
+    DROP COLUMN IF EXISTS simhash;
+DROP TABLE IF EXISTS story_clusters;
+-- +goose StatementEnd
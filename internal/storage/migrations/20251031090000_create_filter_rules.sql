@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE filter_rules (
+    id SERIAL PRIMARY KEY,
+    source_id INTEGER DEFAULT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    action VARCHAR(16) NOT NULL,
+    condition JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS filter_rules;
+-- +goose StatementEnd
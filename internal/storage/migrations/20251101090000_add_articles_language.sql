@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE articles
+    ADD COLUMN language VARCHAR(8) NOT NULL DEFAULT '',
+    ADD COLUMN translate_to VARCHAR(8) NOT NULL DEFAULT '';
+
+ALTER TABLE sources
+    ADD COLUMN languages VARCHAR(255) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sources
+    DROP COLUMN IF EXISTS languages;
+
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS translate_to,
+    DROP COLUMN IF EXISTS language;
+-- +goose StatementEnd
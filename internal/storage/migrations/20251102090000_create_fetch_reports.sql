@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE fetch_reports (
+    id SERIAL PRIMARY KEY,
+    started_at TIMESTAMP NOT NULL,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    sources_attempted INTEGER NOT NULL DEFAULT 0,
+    sources_succeeded INTEGER NOT NULL DEFAULT 0,
+    sources_failed INTEGER NOT NULL DEFAULT 0,
+    items_seen INTEGER NOT NULL DEFAULT 0,
+    items_inserted INTEGER NOT NULL DEFAULT 0,
+    items_duplicate INTEGER NOT NULL DEFAULT 0,
+    items_filtered INTEGER NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX fetch_reports_started_at_idx ON fetch_reports (started_at DESC);
+
+ALTER TABLE fetch_runs
+    ADD COLUMN error_class VARCHAR(32) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE fetch_runs
+    DROP COLUMN IF EXISTS error_class;
+DROP TABLE IF EXISTS fetch_reports;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE articles
+    ADD COLUMN state VARCHAR(16) NOT NULL DEFAULT 'new',
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT NOT NULL DEFAULT '',
+    ADD COLUMN claimed_until TIMESTAMP DEFAULT NULL,
+    ADD COLUMN retry_at TIMESTAMP DEFAULT NULL,
+    ADD COLUMN post_summary TEXT NOT NULL DEFAULT '';
+
+UPDATE articles SET state = 'sent' WHERE posted_at IS NOT NULL;
+
+CREATE INDEX articles_state_idx ON articles (state);
+
+COMMENT ON COLUMN articles.state IS 'new, claimed, summarized, sent or failed';
+COMMENT ON COLUMN articles.post_summary IS 'Summary generated for the post, kept for the retries';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS articles_state_idx;
+ALTER TABLE articles
+    DROP COLUMN IF EXISTS post_summary,
+    DROP COLUMN IF EXISTS retry_at,
+    DROP COLUMN IF EXISTS claimed_until,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS state;
+-- +goose StatementEnd
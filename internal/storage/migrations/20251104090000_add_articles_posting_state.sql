@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+COMMENT ON COLUMN articles.state IS 'new, claimed, summarized, posting, sent, failed or skipped';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE articles SET state = 'failed', claimed_until = NULL WHERE state = 'posting';
+
+COMMENT ON COLUMN articles.state IS 'new, claimed, summarized, sent or failed';
+-- +goose StatementEnd
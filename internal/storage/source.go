@@ -4,18 +4,88 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/ozaitsev92/gonewsbot/internal/model"
 )
 
+const sourceColumns = `
+	id, name, feed_url, source_type, category, priority, options, etag, last_modified, cursor, languages,
+	fetch_interval, next_fetch_at, consecutive_failures, last_error,
+	enabled, disabled_reason, last_item_at, created_at
+`
+
 type dbSource struct {
-	ID        int64     `db:"id"`
-	Name      string    `db:"name"`
-	FeedURL   string    `db:"feed_url"`
-	Priority  int       `db:"priority"`
-	CreatedAt time.Time `db:"created_at"`
+	ID                  int64        `db:"id"`
+	Name                string       `db:"name"`
+	FeedURL             string       `db:"feed_url"`
+	SourceType          string       `db:"source_type"`
+	Category            string       `db:"category"`
+	Priority            int          `db:"priority"`
+	Options             []byte       `db:"options"`
+	ETag                string       `db:"etag"`
+	LastModified        string       `db:"last_modified"`
+	Cursor              string       `db:"cursor"`
+	Languages           string       `db:"languages"`
+	FetchInterval       int64        `db:"fetch_interval"`
+	NextFetchAt         sql.NullTime `db:"next_fetch_at"`
+	ConsecutiveFailures int          `db:"consecutive_failures"`
+	LastError           string       `db:"last_error"`
+	Enabled             bool         `db:"enabled"`
+	DisabledReason      string       `db:"disabled_reason"`
+	LastItemAt          sql.NullTime `db:"last_item_at"`
+	CreatedAt           time.Time    `db:"created_at"`
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSource scans the sourceColumns of a row followed by any extra columns.
+func scanSource(row rowScanner, extra ...any) (dbSource, error) {
+	var src dbSource
+	dest := []any{
+		&src.ID, &src.Name, &src.FeedURL, &src.SourceType, &src.Category, &src.Priority, &src.Options, &src.ETag, &src.LastModified, &src.Cursor, &src.Languages,
+		&src.FetchInterval, &src.NextFetchAt, &src.ConsecutiveFailures, &src.LastError,
+		&src.Enabled, &src.DisabledReason, &src.LastItemAt, &src.CreatedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	return src, err
+}
+
+// prefixColumns qualifies every column of the list with the table alias.
+func prefixColumns(alias string, columns string) string {
+	fields := strings.Split(columns, ",")
+	for i, field := range fields {
+		fields[i] = alias + "." + strings.TrimSpace(field)
+	}
+	return strings.Join(fields, ", ")
+}
+
+func (s dbSource) toModel() model.Source {
+	return model.Source{
+		ID:                  s.ID,
+		Name:                s.Name,
+		FeedURL:             s.FeedURL,
+		Type:                s.SourceType,
+		Category:            s.Category,
+		Priority:            s.Priority,
+		Options:             s.Options,
+		ETag:                s.ETag,
+		LastModified:        s.LastModified,
+		Cursor:              s.Cursor,
+		Languages:           splitLanguages(s.Languages),
+		FetchInterval:       time.Duration(s.FetchInterval) * time.Second,
+		NextFetchAt:         s.NextFetchAt.Time,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		LastError:           s.LastError,
+		Enabled:             s.Enabled,
+		DisabledReason:      s.DisabledReason,
+		LastItemAt:          s.LastItemAt.Time,
+		CreatedAt:           s.CreatedAt,
+	}
 }
 
 type SourcePostgresStorage struct {
@@ -36,15 +106,57 @@ func (s *SourcePostgresStorage) GetSources(ctx context.Context) ([]model.Source,
 	defer conn.Close()
 
 	var sources []dbSource
-	rows, err := conn.QueryContext(ctx, "SELECT * FROM sources")
+	rows, err := conn.QueryContext(ctx, "SELECT "+sourceColumns+" FROM sources")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		src, err := scanSource(rows)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]model.Source, len(sources))
+	for i, src := range sources {
+		result[i] = src.toModel()
+	}
+
+	return result, nil
+}
+
+// GetDueSources returns the sources that were never fetched or whose next fetch time has come.
+func (s *SourcePostgresStorage) GetDueSources(ctx context.Context, now time.Time) ([]model.Source, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var sources []dbSource
+	rows, err := conn.QueryContext(
+		ctx,
+		`
+			SELECT `+sourceColumns+` FROM sources
+			WHERE enabled AND source_type <> $2 AND (next_fetch_at IS NULL OR next_fetch_at <= $1::timestamp)
+		`,
+		now.UTC().Format(time.RFC3339),
+		model.SourceTypePush,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var src dbSource
-		if err := rows.Scan(&src.ID, &src.Name, &src.FeedURL, &src.CreatedAt); err != nil {
+		src, err := scanSource(rows)
+		if err != nil {
 			return nil, err
 		}
 		sources = append(sources, src)
@@ -55,7 +167,7 @@ func (s *SourcePostgresStorage) GetSources(ctx context.Context) ([]model.Source,
 
 	result := make([]model.Source, len(sources))
 	for i, src := range sources {
-		result[i] = model.Source(src)
+		result[i] = src.toModel()
 	}
 
 	return result, nil
@@ -68,17 +180,17 @@ func (s *SourcePostgresStorage) GetSourceByID(ctx context.Context, id int64) (*m
 	}
 	defer conn.Close()
 
-	var src dbSource
-	row := conn.QueryRowContext(ctx, "SELECT * FROM sources WHERE id = $1", id)
+	row := conn.QueryRowContext(ctx, "SELECT "+sourceColumns+" FROM sources WHERE id = $1", id)
 	if err := row.Err(); err != nil {
 		return nil, err
 	}
 
-	if err := row.Scan(&src); err != nil {
+	src, err := scanSource(row)
+	if err != nil {
 		return nil, err
 	}
 
-	result := model.Source(src)
+	result := src.toModel()
 	return &result, nil
 }
 
@@ -89,16 +201,68 @@ func (s *SourcePostgresStorage) AddSource(ctx context.Context, source model.Sour
 	}
 	defer conn.Close()
 
+	sourceType := source.Type
+	if sourceType == "" {
+		sourceType = model.SourceTypeRSS
+	}
+
+	options := source.Options
+	if len(options) == 0 {
+		options = []byte("{}")
+	}
+
 	row := conn.QueryRowContext(
 		ctx,
-		"INSERT INTO sources (name, feed_url, created_at) VALUES ($1, $2, $3) RETURNING id",
+		`
+			INSERT INTO sources (name, feed_url, source_type, category, priority, options, languages, fetch_interval)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id
+		`,
 		source.Name,
 		source.FeedURL,
-		source.CreatedAt,
+		sourceType,
+		source.Category,
+		source.Priority,
+		string(options),
+		strings.Join(source.Languages, ","),
+		int64(source.FetchInterval/time.Second),
 	)
 	if err := row.Err(); err != nil {
+		if isUniqueViolation(err) {
+			return 0, model.ErrSourceExists
+		}
+		return 0, err
+	}
+
+	var id int64
+	if err := row.Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// EnsurePushSource returns the id of the push source with the name and creates it when it does not exist yet.
+// Push sources are never fetched, the articles are submitted to them through the API.
+func (s *SourcePostgresStorage) EnsurePushSource(ctx context.Context, name string) (int64, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
 		return 0, err
 	}
+	defer conn.Close()
+
+	row := conn.QueryRowContext(
+		ctx,
+		`
+			INSERT INTO sources (name, feed_url, source_type)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (feed_url) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		`,
+		name,
+		model.PushSourceURL(name),
+		model.SourceTypePush,
+	)
 
 	var id int64
 	if err := row.Scan(&id); err != nil {
@@ -123,6 +287,198 @@ func (s *SourcePostgresStorage) SetPriority(ctx context.Context, id int64, prior
 	return nil
 }
 
+func (s *SourcePostgresStorage) SetCacheValidators(ctx context.Context, id int64, etag string, lastModified string) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(
+		ctx,
+		"UPDATE sources SET etag = $1, last_modified = $2 WHERE id = $3",
+		etag,
+		lastModified,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SourcePostgresStorage) SetCursor(ctx context.Context, id int64, cursor string) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "UPDATE sources SET cursor = $1 WHERE id = $2", cursor, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *SourcePostgresStorage) SetFetchInterval(ctx context.Context, id int64, interval time.Duration) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(
+		ctx,
+		"UPDATE sources SET fetch_interval = $1, next_fetch_at = NULL WHERE id = $2",
+		int64(interval/time.Second),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetLanguages replaces the allowed languages of the source, none falls back to the global list.
+func (s *SourcePostgresStorage) SetLanguages(ctx context.Context, id int64, languages []string) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "UPDATE sources SET languages = $1 WHERE id = $2", strings.Join(languages, ","), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// splitLanguages parses the comma separated languages column.
+func splitLanguages(column string) []string {
+	if column == "" {
+		return nil
+	}
+
+	return strings.Split(column, ",")
+}
+
+// RecordFetchSuccess schedules the next fetch and clears the failure streak.
+// lastItemAt is left untouched when it is zero, i.e. when the fetch brought no new items.
+func (s *SourcePostgresStorage) RecordFetchSuccess(ctx context.Context, id int64, nextFetchAt time.Time, lastItemAt time.Time) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(
+		ctx,
+		`
+			UPDATE sources
+			SET
+				next_fetch_at = $1::timestamp,
+				consecutive_failures = 0,
+				last_error = '',
+				last_item_at = COALESCE($2::timestamp, last_item_at)
+			WHERE id = $3
+		`,
+		nextFetchAt.UTC().Format(time.RFC3339),
+		nullTime(lastItemAt),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RecordFetchFailure schedules the next attempt and extends the failure streak.
+func (s *SourcePostgresStorage) RecordFetchFailure(ctx context.Context, id int64, nextFetchAt time.Time, lastError string) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(
+		ctx,
+		`
+			UPDATE sources
+			SET next_fetch_at = $1::timestamp, consecutive_failures = consecutive_failures + 1, last_error = $2
+			WHERE id = $3
+		`,
+		nextFetchAt.UTC().Format(time.RFC3339),
+		lastError,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DisableSource stops the source from being fetched until it is enabled again.
+func (s *SourcePostgresStorage) DisableSource(ctx context.Context, id int64, reason string) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(
+		ctx,
+		"UPDATE sources SET enabled = FALSE, disabled_reason = $1 WHERE id = $2",
+		reason,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// EnableSource re-enables the source and schedules it for the next fetch
+// with a clean failure streak. The idle timer restarts from now as well,
+// otherwise a source disabled for inactivity would be disabled right away.
+func (s *SourcePostgresStorage) EnableSource(ctx context.Context, id int64) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(
+		ctx,
+		`
+			UPDATE sources
+			SET
+				enabled = TRUE,
+				disabled_reason = '',
+				consecutive_failures = 0,
+				last_error = '',
+				next_fetch_at = NULL,
+				last_item_at = $1::timestamp
+			WHERE id = $2
+		`,
+		time.Now().UTC().Format(time.RFC3339),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *SourcePostgresStorage) DeleteSource(ctx context.Context, id int64) error {
 	conn, err := s.db.Conn(ctx)
 	if err != nil {
@@ -141,3 +497,10 @@ func (s *SourcePostgresStorage) DeleteSource(ctx context.Context, id int64) erro
 
 	return nil
 }
+
+func nullTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
+}
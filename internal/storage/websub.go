@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+type dbWebSubSubscription struct {
+	SourceID       int64        `db:"source_id"`
+	HubURL         string       `db:"hub_url"`
+	TopicURL       string       `db:"topic_url"`
+	Secret         string       `db:"secret"`
+	State          string       `db:"state"`
+	LeaseExpiresAt sql.NullTime `db:"lease_expires_at"`
+	UpdatedAt      time.Time    `db:"updated_at"`
+}
+
+func (s dbWebSubSubscription) toModel() model.WebSubSubscription {
+	return model.WebSubSubscription{
+		SourceID:       s.SourceID,
+		HubURL:         s.HubURL,
+		TopicURL:       s.TopicURL,
+		Secret:         s.Secret,
+		State:          s.State,
+		LeaseExpiresAt: s.LeaseExpiresAt.Time,
+		UpdatedAt:      s.UpdatedAt,
+	}
+}
+
+type WebSubPostgresStorage struct {
+	db *sqlx.DB
+}
+
+func NewWebSubPostgresStorage(db *sqlx.DB) *WebSubPostgresStorage {
+	return &WebSubPostgresStorage{
+		db: db,
+	}
+}
+
+// GetWebSubSubscription returns nil when the source has no subscription.
+func (s *WebSubPostgresStorage) GetWebSubSubscription(ctx context.Context, sourceID int64) (*model.WebSubSubscription, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var sub dbWebSubSubscription
+	err = conn.QueryRowContext(
+		ctx,
+		`
+			SELECT source_id, hub_url, topic_url, secret, state, lease_expires_at, updated_at
+			FROM websub_subscriptions
+			WHERE source_id = $1
+		`,
+		sourceID,
+	).Scan(&sub.SourceID, &sub.HubURL, &sub.TopicURL, &sub.Secret, &sub.State, &sub.LeaseExpiresAt, &sub.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	result := sub.toModel()
+	return &result, nil
+}
+
+// GetRenewableWebSubSubscriptions returns the subscriptions that were not denied and whose lease ends before the given time.
+func (s *WebSubPostgresStorage) GetRenewableWebSubSubscriptions(ctx context.Context, before time.Time) ([]model.WebSubSubscription, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(
+		ctx,
+		`
+			SELECT source_id, hub_url, topic_url, secret, state, lease_expires_at, updated_at
+			FROM websub_subscriptions
+			WHERE state <> $1 AND lease_expires_at <= $2::timestamp
+		`,
+		model.WebSubStateDenied,
+		before.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []model.WebSubSubscription
+	for rows.Next() {
+		var sub dbWebSubSubscription
+		if err := rows.Scan(&sub.SourceID, &sub.HubURL, &sub.TopicURL, &sub.Secret, &sub.State, &sub.LeaseExpiresAt, &sub.UpdatedAt); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub.toModel())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// SaveWebSubSubscription stores a subscription request. The lease of an earlier
+// subscription is kept, it stays valid until the hub verifies the new request.
+func (s *WebSubPostgresStorage) SaveWebSubSubscription(ctx context.Context, sub model.WebSubSubscription) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(
+		ctx,
+		`
+			INSERT INTO websub_subscriptions (source_id, hub_url, topic_url, secret, state, updated_at)
+			VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+			ON CONFLICT (source_id) DO UPDATE SET
+				hub_url = EXCLUDED.hub_url,
+				topic_url = EXCLUDED.topic_url,
+				secret = EXCLUDED.secret,
+				state = EXCLUDED.state,
+				updated_at = EXCLUDED.updated_at
+		`,
+		sub.SourceID,
+		sub.HubURL,
+		sub.TopicURL,
+		sub.Secret,
+		sub.State,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *WebSubPostgresStorage) ActivateWebSubSubscription(ctx context.Context, sourceID int64, leaseExpiresAt time.Time) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(
+		ctx,
+		`
+			UPDATE websub_subscriptions
+			SET state = $1, lease_expires_at = $2::timestamp, updated_at = CURRENT_TIMESTAMP
+			WHERE source_id = $3
+		`,
+		model.WebSubStateActive,
+		leaseExpiresAt.UTC().Format(time.RFC3339),
+		sourceID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *WebSubPostgresStorage) SetWebSubSubscriptionState(ctx context.Context, sourceID int64, state string) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(
+		ctx,
+		"UPDATE websub_subscriptions SET state = $1, updated_at = CURRENT_TIMESTAMP WHERE source_id = $2",
+		state,
+		sourceID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
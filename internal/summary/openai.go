@@ -25,6 +25,15 @@ func NewOpenAISummarizer(apiKey string, model string, prompt string) *OpenAISumm
 }
 
 func (s *OpenAISummarizer) Summarize(text string) (string, error) {
+	return s.summarize(s.prompt, text)
+}
+
+// SummarizeIn writes the summary in the given language, whatever the language of the text.
+func (s *OpenAISummarizer) SummarizeIn(text string, language string) (string, error) {
+	return s.summarize(s.prompt+"\n\nWrite the summary in "+language+".", text)
+}
+
+func (s *OpenAISummarizer) summarize(prompt string, text string) (string, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -33,7 +42,7 @@ func (s *OpenAISummarizer) Summarize(text string) (string, error) {
 		Messages: []openai.ChatCompletionMessage{
 			{
 				Role:    openai.ChatMessageRoleSystem,
-				Content: s.prompt,
+				Content: prompt,
 			},
 			{
 				Role:    openai.ChatMessageRoleUser,
@@ -0,0 +1,174 @@
+package websub
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+	"github.com/ozaitsev92/gonewsbot/internal/source"
+)
+
+// CallbackPath is the path of the callbacks, followed by the source id.
+const CallbackPath = "/websub/"
+
+// maxContentSize caps the size of a content distribution request.
+const maxContentSize = 10 << 20
+
+var signatureHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// Handler serves the callbacks: GET for the verification of intent and
+// POST for the content distribution. It is mounted on CallbackPath+"{id}".
+func (s *Subscriber) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sourceID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		sub, err := s.subscriptions.GetWebSubSubscription(r.Context(), sourceID)
+		if err != nil {
+			slog.Error("failed to get websub subscription", "source_id", sourceID, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if sub == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			s.verifyIntent(w, r, *sub)
+		case http.MethodPost:
+			s.receiveContent(w, r, *sub)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// verifyIntent confirms the subscriptions this subscriber asked for by
+// echoing the challenge and records denials.
+func (s *Subscriber) verifyIntent(w http.ResponseWriter, r *http.Request, sub model.WebSubSubscription) {
+	query := r.URL.Query()
+	mode := query.Get("hub.mode")
+
+	if query.Get("hub.topic") != sub.TopicURL {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch mode {
+	case "subscribe":
+		leaseSeconds, err := strconv.Atoi(query.Get("hub.lease_seconds"))
+		if err != nil || leaseSeconds <= 0 {
+			leaseSeconds = int(s.lease.Seconds())
+		}
+
+		leaseExpiresAt := s.now().Add(time.Duration(leaseSeconds) * time.Second)
+		if err := s.subscriptions.ActivateWebSubSubscription(r.Context(), sub.SourceID, leaseExpiresAt); err != nil {
+			slog.Error("failed to activate websub subscription", "source_id", sub.SourceID, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		slog.Info("websub subscription verified", "source_id", sub.SourceID, "hub", sub.HubURL, "lease_expires_at", leaseExpiresAt)
+	case "denied":
+		if err := s.subscriptions.SetWebSubSubscriptionState(r.Context(), sub.SourceID, model.WebSubStateDenied); err != nil {
+			slog.Error("failed to record websub denial", "source_id", sub.SourceID, "error", err)
+		}
+
+		slog.Warn("websub subscription denied", "source_id", sub.SourceID, "hub", sub.HubURL, "reason", query.Get("hub.reason"))
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		// Unsubscriptions are never requested, so they are not confirmed either.
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, query.Get("hub.challenge"))
+}
+
+// receiveContent ingests the feed document pushed by the hub. Content with
+// a missing or wrong signature is acknowledged but dropped, as the WebSub
+// specification requires.
+func (s *Subscriber) receiveContent(w http.ResponseWriter, r *http.Request, sub model.WebSubSubscription) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxContentSize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !validSignature(r.Header.Get("X-Hub-Signature"), sub.Secret, body) {
+		slog.Warn("dropped websub content with an invalid signature", "source_id", sub.SourceID)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	sourceType, err := source.DetectType(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		slog.Warn("unknown websub content", "source_id", sub.SourceID, "error", err)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	items, err := source.ParseItems(sourceType, body)
+	if err != nil {
+		slog.Warn("failed to parse websub content", "source_id", sub.SourceID, "error", err)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	added, err := s.articles.Ingest(r.Context(), sub.SourceID, items)
+	if err != nil {
+		// A failed insert is left to the next poll of the feed.
+		slog.Error("failed to ingest websub content", "source_id", sub.SourceID, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("ingested websub content", "source_id", sub.SourceID, "items", len(items), "added", added)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// validSignature checks the X-Hub-Signature header, "<algorithm>=<hex hmac of the body>".
+func validSignature(header string, secret string, body []byte) bool {
+	algorithm, signature, ok := strings.Cut(header, "=")
+	if !ok {
+		return false
+	}
+
+	newHash, ok := signatureHashes[strings.ToLower(algorithm)]
+	if !ok {
+		return false
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,254 @@
+package websub
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const (
+	testTopic  = "https://example.com/feed.xml"
+	testSecret = "s3cret"
+)
+
+const testFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+<channel>
+	<title>Example</title>
+	<link>https://example.com</link>
+	<item>
+		<title>First post</title>
+		<link>https://example.com/first</link>
+	</item>
+</channel>
+</rss>`
+
+// memorySubscriptions keeps the subscriptions of the sources in memory.
+type memorySubscriptions struct {
+	subs map[int64]*model.WebSubSubscription
+}
+
+func (s *memorySubscriptions) GetWebSubSubscription(_ context.Context, sourceID int64) (*model.WebSubSubscription, error) {
+	sub, ok := s.subs[sourceID]
+	if !ok {
+		return nil, nil
+	}
+
+	copied := *sub
+	return &copied, nil
+}
+
+func (s *memorySubscriptions) GetRenewableWebSubSubscriptions(_ context.Context, before time.Time) ([]model.WebSubSubscription, error) {
+	var subs []model.WebSubSubscription
+	for _, sub := range s.subs {
+		if sub.State != model.WebSubStateDenied && sub.LeaseExpiresAt.Before(before) {
+			subs = append(subs, *sub)
+		}
+	}
+
+	return subs, nil
+}
+
+func (s *memorySubscriptions) SaveWebSubSubscription(_ context.Context, sub model.WebSubSubscription) error {
+	s.subs[sub.SourceID] = &sub
+	return nil
+}
+
+func (s *memorySubscriptions) ActivateWebSubSubscription(_ context.Context, sourceID int64, leaseExpiresAt time.Time) error {
+	s.subs[sourceID].State = model.WebSubStateActive
+	s.subs[sourceID].LeaseExpiresAt = leaseExpiresAt
+	return nil
+}
+
+func (s *memorySubscriptions) SetWebSubSubscriptionState(_ context.Context, sourceID int64, state string) error {
+	s.subs[sourceID].State = state
+	return nil
+}
+
+// recordingIngester keeps the items pushed for every source.
+type recordingIngester struct {
+	items map[int64][]model.Item
+}
+
+func (i *recordingIngester) Ingest(_ context.Context, sourceID int64, items []model.Item) (int, error) {
+	i.items[sourceID] = append(i.items[sourceID], items...)
+	return len(items), nil
+}
+
+// newTestCallback serves the callbacks of a subscriber whose source 1 waits
+// for the verification of its subscription to testTopic.
+func newTestCallback(t *testing.T, now time.Time) (*httptest.Server, *memorySubscriptions, *recordingIngester) {
+	t.Helper()
+
+	subscriptions := &memorySubscriptions{subs: map[int64]*model.WebSubSubscription{
+		1: {SourceID: 1, HubURL: "https://hub.example.com", TopicURL: testTopic, Secret: testSecret, State: model.WebSubStatePending},
+	}}
+	ingester := &recordingIngester{items: make(map[int64][]model.Item)}
+
+	subscriber := NewSubscriber(subscriptions, ingester, http.DefaultClient, "https://bot.example.com", 24*time.Hour)
+	subscriber.now = func() time.Time { return now }
+
+	mux := http.NewServeMux()
+	mux.Handle(CallbackPath+"{id}", subscriber.Handler())
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv, subscriptions, ingester
+}
+
+func TestVerifyIntent(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		path       string
+		query      url.Values
+		wantStatus int
+		wantBody   string
+		wantState  string
+		wantLease  time.Time
+	}{
+		{
+			name:       "subscription is confirmed with the challenge",
+			path:       "1",
+			query:      url.Values{"hub.mode": {"subscribe"}, "hub.topic": {testTopic}, "hub.challenge": {"c4a11e"}, "hub.lease_seconds": {"3600"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "c4a11e",
+			wantState:  model.WebSubStateActive,
+			wantLease:  now.Add(time.Hour),
+		},
+		{
+			name:       "missing lease falls back to the requested one",
+			path:       "1",
+			query:      url.Values{"hub.mode": {"subscribe"}, "hub.topic": {testTopic}, "hub.challenge": {"c4a11e"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "c4a11e",
+			wantState:  model.WebSubStateActive,
+			wantLease:  now.Add(24 * time.Hour),
+		},
+		{
+			name:       "other topic",
+			path:       "1",
+			query:      url.Values{"hub.mode": {"subscribe"}, "hub.topic": {"https://example.com/other.xml"}, "hub.challenge": {"c4a11e"}},
+			wantStatus: http.StatusNotFound,
+			wantState:  model.WebSubStatePending,
+		},
+		{
+			name:       "unsubscription is never confirmed",
+			path:       "1",
+			query:      url.Values{"hub.mode": {"unsubscribe"}, "hub.topic": {testTopic}, "hub.challenge": {"c4a11e"}},
+			wantStatus: http.StatusNotFound,
+			wantState:  model.WebSubStatePending,
+		},
+		{
+			name:       "denial is recorded",
+			path:       "1",
+			query:      url.Values{"hub.mode": {"denied"}, "hub.topic": {testTopic}, "hub.reason": {"not allowed"}},
+			wantStatus: http.StatusOK,
+			wantState:  model.WebSubStateDenied,
+		},
+		{
+			name:       "unknown source",
+			path:       "2",
+			query:      url.Values{"hub.mode": {"subscribe"}, "hub.topic": {testTopic}, "hub.challenge": {"c4a11e"}},
+			wantStatus: http.StatusNotFound,
+			wantState:  model.WebSubStatePending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, subscriptions, _ := newTestCallback(t, now)
+
+			resp, err := http.Get(srv.URL + CallbackPath + tt.path + "?" + tt.query.Encode())
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("body = %q, want the challenge %q", body, tt.wantBody)
+			}
+
+			sub := subscriptions.subs[1]
+			if sub.State != tt.wantState {
+				t.Errorf("state = %s, want %s", sub.State, tt.wantState)
+			}
+			if !sub.LeaseExpiresAt.Equal(tt.wantLease) {
+				t.Errorf("lease expires at %v, want %v", sub.LeaseExpiresAt, tt.wantLease)
+			}
+		})
+	}
+}
+
+// sign returns the X-Hub-Signature of the body for the algorithm.
+func sign(algorithm string, newHash func() hash.Hash, secret string, body string) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(body))
+	return algorithm + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestReceiveContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature string
+		wantItems int
+	}{
+		{name: "sha256 signature", signature: sign("sha256", sha256.New, testSecret, testFeed), wantItems: 1},
+		{name: "sha1 signature", signature: sign("sha1", sha1.New, testSecret, testFeed), wantItems: 1},
+		{name: "upper case algorithm", signature: sign("SHA256", sha256.New, testSecret, testFeed), wantItems: 1},
+		{name: "wrong secret", signature: sign("sha256", sha256.New, "guessed", testFeed)},
+		{name: "signature of other content", signature: sign("sha256", sha256.New, testSecret, "<rss></rss>")},
+		{name: "missing signature"},
+		{name: "unsupported algorithm", signature: sign("md5", md5.New, testSecret, testFeed)},
+		{name: "malformed signature", signature: "sha256=not-hex"},
+		{name: "signature without algorithm", signature: strings.TrimPrefix(sign("sha256", sha256.New, testSecret, testFeed), "sha256=")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _, ingester := newTestCallback(t, time.Now())
+
+			req, err := http.NewRequest(http.MethodPost, srv.URL+CallbackPath+"1", strings.NewReader(testFeed))
+			if err != nil {
+				t.Fatalf("NewRequest() error = %v", err)
+			}
+			req.Header.Set("Content-Type", "application/rss+xml")
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature", tt.signature)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("POST error = %v", err)
+			}
+			_ = resp.Body.Close()
+
+			// The hub is never told that the content was dropped.
+			if resp.StatusCode != http.StatusAccepted {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusAccepted)
+			}
+			if got := len(ingester.items[1]); got != tt.wantItems {
+				t.Fatalf("ingested %d items, want %d", got, tt.wantItems)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package websub
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+const (
+	// renewCheckInterval is how often the leases are checked.
+	renewCheckInterval = 30 * time.Minute
+	// renewBefore is how long before the end of a lease it is renewed.
+	renewBefore = 2 * time.Hour
+	// retryAfter is how long a pending or denied subscription is left alone
+	// before the subscription is requested again.
+	retryAfter = 24 * time.Hour
+)
+
+type SubscriptionStorage interface {
+	GetWebSubSubscription(ctx context.Context, sourceID int64) (*model.WebSubSubscription, error)
+	GetRenewableWebSubSubscriptions(ctx context.Context, before time.Time) ([]model.WebSubSubscription, error)
+	SaveWebSubSubscription(ctx context.Context, sub model.WebSubSubscription) error
+	ActivateWebSubSubscription(ctx context.Context, sourceID int64, leaseExpiresAt time.Time) error
+	SetWebSubSubscriptionState(ctx context.Context, sourceID int64, state string) error
+}
+
+type ArticleIngester interface {
+	Ingest(ctx context.Context, sourceID int64, items []model.Item) (int, error)
+}
+
+// Subscriber subscribes sources to the WebSub hubs of their feeds and
+// ingests the content the hubs push to the callback. Polling keeps running
+// next to it, so a hub that stops delivering costs only the latency.
+type Subscriber struct {
+	subscriptions SubscriptionStorage
+	articles      ArticleIngester
+	client        *http.Client
+	callbackURL   string
+	lease         time.Duration
+	now           func() time.Time
+}
+
+// NewSubscriber creates a subscriber whose callbacks are served under
+// callbackURL, the public base URL of the HTTP server.
+func NewSubscriber(
+	subscriptions SubscriptionStorage,
+	articles ArticleIngester,
+	client *http.Client,
+	callbackURL string,
+	lease time.Duration,
+) *Subscriber {
+	return &Subscriber{
+		subscriptions: subscriptions,
+		articles:      articles,
+		client:        client,
+		callbackURL:   strings.TrimSuffix(callbackURL, "/"),
+		lease:         lease,
+		now:           time.Now,
+	}
+}
+
+// EnsureSubscribed subscribes the source at the hub unless it already has a
+// subscription for the same hub and topic that is active or waiting for
+// the verification.
+func (s *Subscriber) EnsureSubscribed(ctx context.Context, sourceID int64, hubURL string, topicURL string) error {
+	sub, err := s.subscriptions.GetWebSubSubscription(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+
+	var secret string
+	if sub != nil && sub.HubURL == hubURL && sub.TopicURL == topicURL {
+		secret = sub.Secret
+
+		switch sub.State {
+		case model.WebSubStateActive:
+			if sub.LeaseExpiresAt.After(s.now().Add(renewBefore)) {
+				return nil
+			}
+		case model.WebSubStatePending, model.WebSubStateDenied:
+			if s.now().Sub(sub.UpdatedAt) < retryAfter {
+				return nil
+			}
+		}
+	}
+
+	return s.subscribe(ctx, sourceID, hubURL, topicURL, secret)
+}
+
+// Start renews the leases that are about to end until the context is cancelled.
+func (s *Subscriber) Start(ctx context.Context) error {
+	ticker := time.NewTicker(renewCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			s.renew(ctx)
+		}
+	}
+}
+
+func (s *Subscriber) renew(ctx context.Context) {
+	subs, err := s.subscriptions.GetRenewableWebSubSubscriptions(ctx, s.now().Add(renewBefore))
+	if err != nil {
+		slog.Error("failed to get websub subscriptions to renew", "error", err)
+		return
+	}
+
+	for _, sub := range subs {
+		// A renewal that is still waiting for the verification of the hub.
+		if sub.State == model.WebSubStatePending && s.now().Sub(sub.UpdatedAt) < renewCheckInterval {
+			continue
+		}
+
+		if err := s.subscribe(ctx, sub.SourceID, sub.HubURL, sub.TopicURL, sub.Secret); err != nil {
+			slog.Error("failed to renew websub subscription", "source_id", sub.SourceID, "hub", sub.HubURL, "error", err)
+		}
+	}
+}
+
+// subscribe sends the subscription request. The hub confirms it
+// asynchronously through the verification of intent on the callback.
+// Renewals keep the secret, so content signed for the current lease
+// is still accepted while the hub verifies the renewal.
+func (s *Subscriber) subscribe(ctx context.Context, sourceID int64, hubURL string, topicURL string, secret string) error {
+	if secret == "" {
+		var err error
+		if secret, err = newSecret(); err != nil {
+			return err
+		}
+	}
+
+	// The request is stored first: the hub may verify it before it answers.
+	err := s.subscriptions.SaveWebSubSubscription(ctx, model.WebSubSubscription{
+		SourceID: sourceID,
+		HubURL:   hubURL,
+		TopicURL: topicURL,
+		Secret:   secret,
+		State:    model.WebSubStatePending,
+	})
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{
+		"hub.mode":          {"subscribe"},
+		"hub.topic":         {topicURL},
+		"hub.callback":      {s.callbackFor(sourceID)},
+		"hub.secret":        {secret},
+		"hub.lease_seconds": {strconv.Itoa(int(s.lease.Seconds()))},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hubURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("hub %s rejected the subscription with status code %d", hubURL, resp.StatusCode)
+	}
+
+	slog.Info("requested websub subscription", "source_id", sourceID, "hub", hubURL, "topic", topicURL)
+
+	return nil
+}
+
+func (s *Subscriber) callbackFor(sourceID int64) string {
+	return s.callbackURL + CallbackPath + strconv.FormatInt(sourceID, 10)
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,144 @@
+package websub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ozaitsev92/gonewsbot/internal/model"
+)
+
+// serveHub records the subscription requests it receives.
+func serveHub(t *testing.T) (*httptest.Server, *[]url.Values) {
+	t.Helper()
+
+	var requests []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+		}
+		requests = append(requests, r.PostForm)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func TestEnsureSubscribed(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		existing    *model.WebSubSubscription
+		topic       string
+		wantRequest bool
+		keepSecret  bool
+	}{
+		{
+			name:        "new subscription",
+			topic:       testTopic,
+			wantRequest: true,
+		},
+		{
+			name:     "active lease",
+			existing: &model.WebSubSubscription{State: model.WebSubStateActive, LeaseExpiresAt: now.Add(24 * time.Hour)},
+			topic:    testTopic,
+		},
+		{
+			name:        "lease about to end is renewed with the same secret",
+			existing:    &model.WebSubSubscription{State: model.WebSubStateActive, LeaseExpiresAt: now.Add(time.Hour)},
+			topic:       testTopic,
+			wantRequest: true,
+			keepSecret:  true,
+		},
+		{
+			name:     "recent request waits for the verification",
+			existing: &model.WebSubSubscription{State: model.WebSubStatePending, UpdatedAt: now.Add(-time.Hour)},
+			topic:    testTopic,
+		},
+		{
+			name:        "denial is retried a day later",
+			existing:    &model.WebSubSubscription{State: model.WebSubStateDenied, UpdatedAt: now.Add(-25 * time.Hour)},
+			topic:       testTopic,
+			wantRequest: true,
+			keepSecret:  true,
+		},
+		{
+			name:        "moved topic gets a new secret",
+			existing:    &model.WebSubSubscription{State: model.WebSubStateActive, LeaseExpiresAt: now.Add(24 * time.Hour)},
+			topic:       "https://example.com/atom.xml",
+			wantRequest: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub, requests := serveHub(t)
+
+			subscriptions := &memorySubscriptions{subs: make(map[int64]*model.WebSubSubscription)}
+			if tt.existing != nil {
+				existing := *tt.existing
+				existing.SourceID, existing.HubURL, existing.TopicURL, existing.Secret = 1, hub.URL, testTopic, testSecret
+				subscriptions.subs[1] = &existing
+			}
+
+			subscriber := NewSubscriber(subscriptions, nil, hub.Client(), "https://bot.example.com/", 24*time.Hour)
+			subscriber.now = func() time.Time { return now }
+
+			if err := subscriber.EnsureSubscribed(context.Background(), 1, hub.URL, tt.topic); err != nil {
+				t.Fatalf("EnsureSubscribed() error = %v", err)
+			}
+
+			if !tt.wantRequest {
+				if len(*requests) != 0 {
+					t.Fatalf("hub got %d requests, want none", len(*requests))
+				}
+				return
+			}
+			if len(*requests) != 1 {
+				t.Fatalf("hub got %d requests, want 1", len(*requests))
+			}
+
+			form := (*requests)[0]
+			sub := subscriptions.subs[1]
+			want := url.Values{
+				"hub.mode":          {"subscribe"},
+				"hub.topic":         {tt.topic},
+				"hub.callback":      {"https://bot.example.com/websub/1"},
+				"hub.secret":        {sub.Secret},
+				"hub.lease_seconds": {"86400"},
+			}
+			for key := range want {
+				if form.Get(key) != want.Get(key) {
+					t.Errorf("%s = %q, want %q", key, form.Get(key), want.Get(key))
+				}
+			}
+
+			// The request is stored before the hub verifies it on the callback.
+			if sub.State != model.WebSubStatePending || sub.TopicURL != tt.topic {
+				t.Errorf("stored subscription = %s of %s, want pending of %s", sub.State, sub.TopicURL, tt.topic)
+			}
+			if (sub.Secret == testSecret) != tt.keepSecret || sub.Secret == "" {
+				t.Errorf("secret = %q, kept = %v, want kept = %v", sub.Secret, sub.Secret == testSecret, tt.keepSecret)
+			}
+		})
+	}
+}
+
+func TestEnsureSubscribedRejected(t *testing.T) {
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(hub.Close)
+
+	subscriptions := &memorySubscriptions{subs: make(map[int64]*model.WebSubSubscription)}
+	subscriber := NewSubscriber(subscriptions, nil, hub.Client(), "https://bot.example.com", 24*time.Hour)
+
+	if err := subscriber.EnsureSubscribed(context.Background(), 1, hub.URL, testTopic); err == nil {
+		t.Fatal("EnsureSubscribed() error = nil, want the rejection of the hub")
+	}
+}